@@ -0,0 +1,32 @@
+package biathlon
+
+import "fmt"
+
+// ANSI SGR codes for Colorize.
+const (
+	ColorBold   = 1
+	ColorRed    = 31
+	ColorGreen  = 32
+	ColorYellow = 33
+)
+
+// Colorize wraps s in the ANSI escape sequence for code, e.g. ColorGreen, and
+// resets all attributes after it.
+func Colorize(s string, code int) string {
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", code, s)
+}
+
+// statusColor returns the color of a result status in colored text reports,
+// or zero if it is not highlighted.
+func statusColor(status string) int {
+	switch status {
+	case "Finished":
+		return ColorGreen
+	case "NotFinished", "Withdrew":
+		return ColorYellow
+	case "Disqualified":
+		return ColorRed
+	default:
+		return 0
+	}
+}
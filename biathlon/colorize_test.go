@@ -0,0 +1,44 @@
+package biathlon
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestColorize(t *testing.T) {
+	if got := Colorize("Finished", ColorGreen); got != "\x1b[32mFinished\x1b[0m" {
+		t.Errorf("Unexpected colorized string %q", got)
+	}
+}
+
+func TestWriteReportColor(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3000, PenaltyLen: 150}
+	start := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+	competitors := map[int]*Competitor{
+		1: {
+			ID:              1,
+			Status:          "Finished",
+			ActualStartTime: start,
+			FinishTime:      start.Add(25 * time.Minute),
+			LapTimes:        []time.Duration{15 * time.Minute, 10 * time.Minute},
+		},
+		2: {ID: 2, Status: "NotFinished", LapTimes: []time.Duration{12 * time.Minute}},
+		3: {ID: 3, Status: "Disqualified"},
+		4: {ID: 4, Status: "NotStarted"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatColor); err != nil {
+		t.Fatalf("Unexpected error writing colored report: %v", err)
+	}
+
+	expected := "\nFinal Results:\n" +
+		"1. \x1b[32m[00:25:00.000]\x1b[0m 1 [{00:15:00.000, 3.333}, \x1b[1m{00:10:00.000, 5.000}\x1b[0m] {,} 0/0 +00:00.000 (negative split on laps 2)\n" +
+		"\x1b[33m[NotFinished]\x1b[0m 2 [{00:12:00.000, 4.167}, {,}] {,} 0/0 NT\n" +
+		"\x1b[31m[Disqualified]\x1b[0m 3 [{,}, {,}] {,} 0/0 NT\n" +
+		"[NotStarted] 4 [{,}, {,}] {,} 0/0 NT\n"
+	if buf.String() != expected {
+		t.Errorf("Expected report:\n%q\ngot:\n%q", expected, buf.String())
+	}
+}
@@ -0,0 +1,292 @@
+package biathlon
+
+import (
+	"fmt"
+	"time"
+)
+
+type Competitor struct {
+	ID                     int
+	Name                   string
+	Nation                 string // from the start list, if any
+	Bib                    int
+	Status                 string // "Finished", "NotFinished", "Withdrew", "NotStarted", "Disqualified"
+	RegisteredTime         time.Time
+	PlannedStartTime       time.Time
+	ActualStartTime        time.Time
+	FinishTime             time.Time
+	CurrentLap             int
+	LapTimes               []time.Duration
+	LapStartTimes          []time.Time
+	LapLengths             []int // course length of every lap when it was started
+	PenaltyTimes           []time.Duration
+	PenaltyVisits          []int // range visit each penalty segment is booked to, -1 if none
+	PenaltyStartTimes      []time.Time
+	PenaltyEndTimes        []time.Time
+	TotalPenaltyTime       time.Duration
+	PenaltyTimePerLap      []time.Duration // indexed by lap, 0-based
+	Hits                   int
+	Shots                  int
+	CurrentFiringRange     int
+	PerRangeShots          map[int]int // shots fired keyed by firing range
+	RangeAccuracy          []float64   // hits per target for every firing range visit
+	DNFReason              string
+	DNFTime                time.Time
+	DisqualificationReason string
+	Resumed                bool // resumed with event 12 after event 11
+	ResumeReason           string
+	Splits                 []SplitTime
+	RangeVisits            []RangeVisit
+	Corrections            []Correction // official time corrections (event 14), oldest first
+	Anomalies              []string     // unreconciled events for officials to review
+}
+
+func newCompetitor(id int, name string, registered time.Time) *Competitor {
+	return &Competitor{
+		ID:              id,
+		Name:            name,
+		RegisteredTime:  registered,
+		Status:          "NotStarted", // Default status
+		LapTimes:        make([]time.Duration, 0),
+		LapStartTimes:   make([]time.Time, 0),
+		PenaltyTimes:    make([]time.Duration, 0),
+		PenaltyEndTimes: make([]time.Time, 0),
+		Shots:           0,
+		Hits:            0,
+	}
+}
+
+// RangeVisit is one shooting bout: a visit to a firing range (events 5 to 7)
+// together with the penalty laps that followed it.
+type RangeVisit struct {
+	Lap                  int
+	FiringRange          int
+	Enter                time.Time
+	Leave                time.Time // zero while still on the range
+	Hits                 int
+	Misses               int
+	ExpectedPenaltyLoops int           // misses × penaltyLoopsPerMiss
+	PenaltyTime          time.Duration // measured between events 8 and 9
+	PenaltyLen           int           // penalty loop length when the penalty laps were entered
+}
+
+// PenaltyLoops returns the number of penalty loops owed for all range visits, or one
+// if none are known but the competitor did run penalty laps.
+func (c *Competitor) PenaltyLoops() int {
+	loops := 0
+	for _, visit := range c.RangeVisits {
+		loops += visit.ExpectedPenaltyLoops
+	}
+	if loops == 0 && c.TotalPenaltyTime > 0 {
+		return 1
+	}
+
+	return loops
+}
+
+// SplitTime is a competitor passing an intermediate checkpoint (event 13).
+type SplitTime struct {
+	CheckpointID int
+	Lap          int
+	At           time.Time
+	Elapsed      time.Duration // race time at the checkpoint
+	Gap          time.Duration // behind the fastest competitor at this checkpoint so far
+}
+
+// DetectNegativeSplits returns the 1-based numbers of the laps completed
+// faster than the first lap.
+func (c *Competitor) DetectNegativeSplits() []int {
+	var laps []int
+	for i := 1; i < len(c.LapTimes); i++ {
+		if c.LapTimes[i] < c.LapTimes[0] {
+			laps = append(laps, i+1)
+		}
+	}
+
+	return laps
+}
+
+// Label identifies the competitor in output lines, e.g. "competitor(1)" or
+// "competitor Anna Svensson(1)" when the name is known.
+func (c *Competitor) Label() string {
+	if c.Name == "" {
+		return fmt.Sprintf("competitor(%d)", c.ID)
+	}
+
+	return fmt.Sprintf("competitor %s(%d)", c.Name, c.ID)
+}
+
+// IsOnCourse reports whether the competitor is racing right now: started and
+// not finished, stopped or disqualified. A competitor who resumed after event
+// 11 is on course again.
+func (c *Competitor) IsOnCourse() bool {
+	return c.Status == "Started" && c.FinishTime.IsZero() && c.DisqualificationReason == ""
+}
+
+// IsOnFiringRange reports whether the competitor entered a firing range (event
+// 5) and has not left it yet (event 7).
+func (c *Competitor) IsOnFiringRange() bool {
+	return len(c.RangeVisits) > 0 && c.RangeVisits[len(c.RangeVisits)-1].Leave.IsZero()
+}
+
+// IsInPenaltyLoop reports whether the competitor entered the penalty laps
+// (event 8) and has not left them yet (event 9).
+func (c *Competitor) IsInPenaltyLoop() bool {
+	return len(c.PenaltyStartTimes) > len(c.PenaltyEndTimes)
+}
+
+// ShotAccuracy returns the share of shots that hit a target, from 0 to 1, or
+// 0 if the competitor fired no shots.
+func (c *Competitor) ShotAccuracy() float64 {
+	if c.Shots == 0 {
+		return 0
+	}
+
+	return float64(c.Hits) / float64(c.Shots)
+}
+
+// ShotAccuracyPercent formats ShotAccuracy as e.g. "85.0%", or "N/A" if the
+// competitor fired no shots.
+func (c *Competitor) ShotAccuracyPercent() string {
+	if c.Shots == 0 {
+		return "N/A"
+	}
+
+	return fmt.Sprintf("%.1f%%", c.ShotAccuracy()*100)
+}
+
+// addShots counts shots fired at the current firing range.
+func (c *Competitor) addShots(shots int) {
+	c.Shots += shots
+	if c.PerRangeShots == nil {
+		c.PerRangeShots = make(map[int]int)
+	}
+	c.PerRangeShots[c.CurrentFiringRange] += shots
+}
+
+// TotalRaceTime returns the time from start to finish, or zero unless the
+// competitor finished. Like all race times it is counted from the planned
+// start when there is one.
+func (c *Competitor) TotalRaceTime() time.Duration {
+	if c.Status != "Finished" {
+		return 0
+	}
+
+	return c.elapsed(c.FinishTime)
+}
+
+// elapsed returns the race time at t. It is measured from the planned start,
+// as is official, or from the actual start if no start time was planned.
+func (c *Competitor) elapsed(t time.Time) time.Duration {
+	if c.PlannedStartTime.IsZero() {
+		return t.Sub(c.ActualStartTime)
+	}
+
+	return t.Sub(c.PlannedStartTime)
+}
+
+type LapStats struct {
+	Time        string        `json:"time"`
+	Speed       float64       `json:"speed"`
+	PenaltyTime string        `json:"penaltyTime,omitempty"`
+	Duration    time.Duration `json:"-"` // Time unformatted
+}
+
+// lapLength returns the length of the given 0-based lap as measured when the
+// competitor started it, or as configured if that is not known.
+func (c *Competitor) lapLength(lap int, config Configuration) int {
+	if lap < len(c.LapLengths) {
+		return c.LapLengths[lap]
+	}
+
+	return config.LapLength(lap)
+}
+
+// penaltyDistance returns the length of all penalty loops owed, each loop as
+// long as it was measured when the competitor entered the penalty laps.
+func (c *Competitor) penaltyDistance(config Configuration) int {
+	distance, loops := 0, 0
+	for _, visit := range c.RangeVisits {
+		penaltyLen := visit.PenaltyLen
+		if penaltyLen == 0 {
+			penaltyLen = config.PenaltyLen
+		}
+		distance += visit.ExpectedPenaltyLoops * penaltyLen
+		loops += visit.ExpectedPenaltyLoops
+	}
+	if loops == 0 && c.TotalPenaltyTime > 0 {
+		return config.PenaltyLen
+	}
+
+	return distance
+}
+
+// penaltySegmentDistance returns the length of the penalty loops owed for the
+// range visit penalty segment i is booked to, or one configured loop if none
+// are owed.
+func (c *Competitor) penaltySegmentDistance(i int, config Configuration) int {
+	if i >= len(c.PenaltyVisits) || c.PenaltyVisits[i] < 0 || c.PenaltyVisits[i] >= len(c.RangeVisits) {
+		return config.PenaltyLen
+	}
+	visit := c.RangeVisits[c.PenaltyVisits[i]]
+	if visit.ExpectedPenaltyLoops == 0 {
+		return config.PenaltyLen
+	}
+	penaltyLen := visit.PenaltyLen
+	if penaltyLen == 0 {
+		penaltyLen = config.PenaltyLen
+	}
+
+	return visit.ExpectedPenaltyLoops * penaltyLen
+}
+
+// CalculateStats returns the time, average speed and penalty time of every
+// completed lap, and the time and average speed of every penalty segment (an
+// event 8 to event 9 interval). A segment's speed covers the penalty loops owed
+// for the range visit it follows.
+func (c *Competitor) CalculateStats(config Configuration) ([]LapStats, []LapStats) {
+	lapStats := make([]LapStats, len(c.LapTimes))
+	for i, lapTime := range c.LapTimes {
+		var speed float64
+		if lapTime > 0 {
+			speed = float64(c.lapLength(i, config)) / lapTime.Seconds()
+		}
+		lapStats[i] = LapStats{
+			Time:     formatDuration(lapTime),
+			Speed:    speed,
+			Duration: lapTime,
+		}
+		if i < len(c.PenaltyTimePerLap) && c.PenaltyTimePerLap[i] > 0 {
+			lapStats[i].PenaltyTime = formatDuration(c.PenaltyTimePerLap[i])
+		}
+	}
+
+	penaltyStats := make([]LapStats, 0, len(c.PenaltyTimes))
+	for i, penaltyTime := range c.PenaltyTimes {
+		if penaltyTime <= 0 {
+			continue
+		}
+		penaltyStats = append(penaltyStats, LapStats{
+			Time:     formatDuration(penaltyTime),
+			Speed:    float64(c.penaltySegmentDistance(i, config)) / penaltyTime.Seconds(),
+			Duration: penaltyTime,
+		})
+	}
+
+	return lapStats, penaltyStats
+}
+
+// TotalPenaltyStats returns the time and average speed of all penalty laps
+// combined, or zero stats without penalty laps. The speed covers every
+// penalty loop owed.
+func (c *Competitor) TotalPenaltyStats(config Configuration) LapStats {
+	if c.TotalPenaltyTime <= 0 {
+		return LapStats{}
+	}
+
+	return LapStats{
+		Time:     formatDuration(c.TotalPenaltyTime),
+		Speed:    float64(c.penaltyDistance(config)) / c.TotalPenaltyTime.Seconds(),
+		Duration: c.TotalPenaltyTime,
+	}
+}
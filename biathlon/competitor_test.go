@@ -0,0 +1,320 @@
+package biathlon
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompetitorStats(t *testing.T) {
+	config := Configuration{
+		Laps:       2,
+		LapLen:     3500,
+		PenaltyLen: 150,
+	}
+
+	competitor := Competitor{
+		ID:     1,
+		Status: "Finished",
+		LapTimes: []time.Duration{
+			10 * time.Minute,
+			12 * time.Minute,
+		},
+		TotalPenaltyTime: 2 * time.Minute,
+		Hits:             4,
+		Shots:            5,
+	}
+
+	// Calculate stats
+	lapStats, _ := competitor.CalculateStats(config)
+	penaltyStats := competitor.TotalPenaltyStats(config)
+
+	// Check lap stats
+	if len(lapStats) != 2 {
+		t.Errorf("Expected 2 lap stats, got %d", len(lapStats))
+	}
+
+	// Check first lap
+	if lapStats[0].Time != "00:10:00.000" {
+		t.Errorf("Expected first lap time 00:10:00.000, got %s", lapStats[0].Time)
+	}
+
+	expectedSpeed := float64(3500) / (10 * 60)
+	if lapStats[0].Speed != expectedSpeed {
+		t.Errorf("Expected first lap speed %.3f, got %.3f", expectedSpeed, lapStats[0].Speed)
+	}
+
+	// Check penalty stats
+	if penaltyStats.Time != "00:02:00.000" {
+		t.Errorf("Expected penalty time 00:02:00.000, got %s", penaltyStats.Time)
+	}
+
+	expectedPenaltySpeed := float64(150) / (2 * 60)
+	if penaltyStats.Speed != expectedPenaltySpeed {
+		t.Errorf("Expected penalty speed %.3f, got %.3f", expectedPenaltySpeed, penaltyStats.Speed)
+	}
+}
+
+func TestCompetitorStatsZeroLap(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150}
+	competitor := Competitor{ID: 1, LapTimes: []time.Duration{10 * time.Minute, 0}}
+
+	lapStats, _ := competitor.CalculateStats(config)
+	if lapStats[1].Speed != 0 {
+		t.Errorf("Expected a zero-length lap to have speed 0, got %v", lapStats[1].Speed)
+	}
+}
+
+func TestCompetitorPenaltySegments(t *testing.T) {
+	config := Configuration{Laps: 3, LapLen: 3500, PenaltyLen: 150}
+	competitor := Competitor{
+		ID:               1,
+		PenaltyTimes:     []time.Duration{50 * time.Second, 90 * time.Second},
+		PenaltyVisits:    []int{0, 1},
+		TotalPenaltyTime: 140 * time.Second,
+		RangeVisits: []RangeVisit{
+			{FiringRange: 1, ExpectedPenaltyLoops: 1, PenaltyTime: 50 * time.Second, PenaltyLen: 150},
+			{FiringRange: 2, ExpectedPenaltyLoops: 2, PenaltyTime: 90 * time.Second, PenaltyLen: 100},
+		},
+	}
+
+	_, penaltyStats := competitor.CalculateStats(config)
+	expected := []LapStats{
+		{Time: "00:00:50.000", Speed: 150.0 / 50, Duration: 50 * time.Second},
+		{Time: "00:01:30.000", Speed: 200.0 / 90, Duration: 90 * time.Second},
+	}
+	if !reflect.DeepEqual(penaltyStats, expected) {
+		t.Errorf("Expected penalty segments %+v, got %+v", expected, penaltyStats)
+	}
+
+	total := competitor.TotalPenaltyStats(config)
+	if total.Time != "00:02:20.000" || total.Speed != 350.0/140 {
+		t.Errorf("Expected total penalty stats {00:02:20.000, %.3f}, got %+v", 350.0/140, total)
+	}
+}
+
+func TestCompetitorStatsLapLens(t *testing.T) {
+	competitor := Competitor{
+		ID:       1,
+		LapTimes: []time.Duration{10 * time.Minute, 12 * time.Minute},
+	}
+
+	tests := []struct {
+		name     string
+		lapLens  []int
+		expected []float64
+	}{
+		{"uniform", nil, []float64{3500.0 / 600, 3500.0 / 720}},
+		{"shortened final lap", []int{3500, 3000}, []float64{3500.0 / 600, 3000.0 / 720}},
+		{"mismatched lapLens", []int{3000}, []float64{3500.0 / 600, 3500.0 / 720}},
+	}
+
+	for _, test := range tests {
+		config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150, LapLens: test.lapLens}
+
+		lapStats, _ := competitor.CalculateStats(config)
+		for i, speed := range test.expected {
+			if lapStats[i].Speed != speed {
+				t.Errorf("%s: expected lap %d speed %.3f, got %.3f", test.name, i+1, speed, lapStats[i].Speed)
+			}
+		}
+	}
+}
+
+func TestDetectNegativeSplits(t *testing.T) {
+	tests := []struct {
+		name     string
+		lapTimes []time.Duration
+		expected []int
+	}{
+		{"no laps", nil, nil},
+		{"single lap", []time.Duration{10 * time.Minute}, nil},
+		{"slower laps", []time.Duration{10 * time.Minute, 11 * time.Minute, 10 * time.Minute}, nil},
+		{"faster laps", []time.Duration{10 * time.Minute, 9 * time.Minute, 11 * time.Minute, 9 * time.Minute}, []int{2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			competitor := &Competitor{LapTimes: tt.lapTimes}
+			if got := competitor.DetectNegativeSplits(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected negative splits %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCompetitorTotalRaceTime(t *testing.T) {
+	planned, _ := parseTime("[10:00:00.000]")
+
+	tests := []struct {
+		name       string
+		competitor Competitor
+		expected   time.Duration
+	}{
+		{
+			name: "on time",
+			competitor: Competitor{
+				Status:           "Finished",
+				PlannedStartTime: planned,
+				ActualStartTime:  planned,
+				FinishTime:       planned.Add(20 * time.Minute),
+			},
+			expected: 20 * time.Minute,
+		},
+		{
+			name: "early start",
+			competitor: Competitor{
+				Status:           "Finished",
+				PlannedStartTime: planned,
+				ActualStartTime:  planned.Add(-500 * time.Millisecond),
+				FinishTime:       planned.Add(20 * time.Minute),
+			},
+			expected: 20 * time.Minute,
+		},
+		{
+			name: "late start",
+			competitor: Competitor{
+				Status:           "Finished",
+				PlannedStartTime: planned,
+				ActualStartTime:  planned.Add(20 * time.Second),
+				FinishTime:       planned.Add(20 * time.Minute),
+			},
+			expected: 20 * time.Minute,
+		},
+		{
+			name: "without planned start",
+			competitor: Competitor{
+				Status:          "Finished",
+				ActualStartTime: planned,
+				FinishTime:      planned.Add(20 * time.Minute),
+			},
+			expected: 20 * time.Minute,
+		},
+		{
+			name:       "not finished",
+			competitor: Competitor{Status: "NotFinished", ActualStartTime: planned},
+			expected:   0,
+		},
+		{
+			name:       "zero value",
+			competitor: Competitor{},
+			expected:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.competitor.TotalRaceTime(); got != tt.expected {
+				t.Errorf("Expected total race time %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCompetitorIsOnCourse(t *testing.T) {
+	at := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		competitor Competitor
+		expected   bool
+	}{
+		{"registered", Competitor{Status: "NotStarted"}, false},
+		{"started", Competitor{Status: "Started", ActualStartTime: at}, true},
+		{"finished", Competitor{Status: "Finished", FinishTime: at}, false},
+		{"finish time without status", Competitor{Status: "Started", FinishTime: at}, false},
+		{"not finished", Competitor{Status: "NotFinished", DNFTime: at}, false},
+		{"withdrew", Competitor{Status: "Withdrew", DNFTime: at}, false},
+		{"resumed", Competitor{Status: "Started", DNFTime: at, Resumed: true}, true},
+		{"disqualified", Competitor{Status: "Disqualified", DisqualificationReason: "late start"}, false},
+		{"disqualification without status", Competitor{Status: "Started", DisqualificationReason: "late start"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.competitor.IsOnCourse(); got != tt.expected {
+				t.Errorf("Expected IsOnCourse %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCompetitorIsOnFiringRange(t *testing.T) {
+	enter := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+	leave := enter.Add(time.Minute)
+	tests := []struct {
+		name     string
+		visits   []RangeVisit
+		expected bool
+	}{
+		{"no visits", nil, false},
+		{"on the range", []RangeVisit{{FiringRange: 1, Enter: enter}}, true},
+		{"left the range", []RangeVisit{{FiringRange: 1, Enter: enter, Leave: leave}}, false},
+		{"on the second range", []RangeVisit{{FiringRange: 1, Enter: enter, Leave: leave}, {FiringRange: 2, Enter: leave}}, true},
+		{"left both ranges", []RangeVisit{
+			{FiringRange: 1, Enter: enter, Leave: leave},
+			{FiringRange: 2, Enter: leave, Leave: leave.Add(time.Minute)},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			competitor := &Competitor{RangeVisits: tt.visits}
+			if got := competitor.IsOnFiringRange(); got != tt.expected {
+				t.Errorf("Expected IsOnFiringRange %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCompetitorIsInPenaltyLoop(t *testing.T) {
+	enter := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+	leave := enter.Add(time.Minute)
+	tests := []struct {
+		name     string
+		starts   []time.Time
+		ends     []time.Time
+		expected bool
+	}{
+		{"no penalty laps", nil, nil, false},
+		{"in the penalty laps", []time.Time{enter}, nil, true},
+		{"left the penalty laps", []time.Time{enter}, []time.Time{leave}, false},
+		{"in the second penalty laps", []time.Time{enter, leave.Add(time.Hour)}, []time.Time{leave}, true},
+		{"left without entering", nil, []time.Time{leave}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			competitor := &Competitor{PenaltyStartTimes: tt.starts, PenaltyEndTimes: tt.ends}
+			if got := competitor.IsInPenaltyLoop(); got != tt.expected {
+				t.Errorf("Expected IsInPenaltyLoop %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCompetitorShotAccuracy(t *testing.T) {
+	tests := []struct {
+		name     string
+		hits     int
+		shots    int
+		expected float64
+		percent  string
+	}{
+		{"no shots", 0, 0, 0, "N/A"},
+		{"all hits", 10, 10, 1, "100.0%"},
+		{"hits and misses", 17, 20, 0.85, "85.0%"},
+		{"one in three", 1, 3, 1.0 / 3, "33.3%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			competitor := &Competitor{Hits: tt.hits, Shots: tt.shots}
+			if got := competitor.ShotAccuracy(); got != tt.expected {
+				t.Errorf("Expected ShotAccuracy %v, got %v", tt.expected, got)
+			}
+			if got := competitor.ShotAccuracyPercent(); got != tt.percent {
+				t.Errorf("Expected ShotAccuracyPercent %q, got %q", tt.percent, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package biathlon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type Configuration struct {
+	Laps        int    `json:"laps" yaml:"laps" toml:"laps"`
+	LapLen      int    `json:"lapLen" yaml:"lapLen" toml:"lapLen"`
+	PenaltyLen  int    `json:"penaltyLen" yaml:"penaltyLen" toml:"penaltyLen"`
+	FiringLines int    `json:"firingLines" yaml:"firingLines" toml:"firingLines"`
+	Start       string `json:"start" yaml:"start" toml:"start"`
+	StartDelta  string `json:"startDelta" yaml:"startDelta" toml:"startDelta"`
+
+	// StartTolerance is how long after the planned start time a competitor may
+	// start without being disqualified, e.g. "00:00:30.000". When empty the
+	// whole StartDelta is the start window.
+	StartTolerance string `json:"startTolerance,omitempty" yaml:"startTolerance,omitempty" toml:"startTolerance,omitempty"`
+
+	// MassStart gives every competitor the planned start time Start, so a
+	// start time drawn with event 2 is ignored with a warning.
+	MassStart bool `json:"massStart,omitempty" yaml:"massStart,omitempty" toml:"massStart,omitempty"`
+
+	// TargetsPerLine is the number of shots fired on every visit to a firing
+	// line. Zero means the standard five.
+	TargetsPerLine int `json:"targetsPerLine,omitempty" yaml:"targetsPerLine,omitempty" toml:"targetsPerLine,omitempty"`
+
+	// PenaltyLoopsPerMiss is the number of penalty loops owed for every
+	// missed target. Zero means one loop per miss.
+	PenaltyLoopsPerMiss int `json:"penaltyLoopsPerMiss,omitempty" yaml:"penaltyLoopsPerMiss,omitempty" toml:"penaltyLoopsPerMiss,omitempty"`
+
+	// LapLens optionally gives the length of every lap, e.g. for a shortened
+	// final loop. When set it must have one entry per lap and overrides LapLen.
+	LapLens []int `json:"lapLens,omitempty" yaml:"lapLens,omitempty" toml:"lapLens,omitempty"`
+
+	// GracePeriodSeconds is how long after event 11 a competitor may resume
+	// the race with event 12. Zero disables resuming.
+	GracePeriodSeconds int `json:"gracePeriodSeconds" yaml:"gracePeriodSeconds" toml:"gracePeriodSeconds"`
+
+	// LapDebounceMillis ignores a competitor's event 10 that follows their
+	// previous one within this many milliseconds, as lap sensors sometimes
+	// fire twice. Zero keeps every event 10.
+	LapDebounceMillis int `json:"lapDebounceMillis,omitempty" yaml:"lapDebounceMillis,omitempty" toml:"lapDebounceMillis,omitempty"`
+
+	// NationScoreCount is the number of best finishers whose times add up to
+	// a nation's score in the text report. Zero means three.
+	NationScoreCount int `json:"nationScoreCount,omitempty" yaml:"nationScoreCount,omitempty" toml:"nationScoreCount,omitempty"`
+
+	// SpeedUnit is the unit reports give speeds in: SpeedUnitMetersPerSecond
+	// (the default), SpeedUnitKilometersPerHour or SpeedUnitPace.
+	SpeedUnit string `json:"speedUnit,omitempty" yaml:"speedUnit,omitempty" toml:"speedUnit,omitempty"`
+
+	// SpeedDecimals is the number of decimals of reported speeds, or of the
+	// seconds of a pace. Nil means three.
+	SpeedDecimals *int `json:"speedDecimals,omitempty" yaml:"speedDecimals,omitempty" toml:"speedDecimals,omitempty"`
+
+	// Competitors limits the final results to these competitor IDs, e.g. the
+	// ones involved in a protest. Places and gaps are still those in the
+	// whole field. Only the individual results are filtered: the nation and
+	// relay standings and the race summary still cover the whole field, as a
+	// team's score depends on all its members. Empty means every competitor.
+	Competitors []int `json:"competitors,omitempty" yaml:"competitors,omitempty" toml:"competitors,omitempty"`
+
+	// RelayMode ranks relay teams, formed by the baton events 18 and 19,
+	// in addition to the individual results. Only the text and json-race
+	// reports include the team ranking; the CSV, Markdown, HTML and XML
+	// reports have the individual results alone.
+	RelayMode bool `json:"relayMode,omitempty" yaml:"relayMode,omitempty" toml:"relayMode,omitempty"`
+
+	// Generator names the program that wrote the reports, e.g. "biathlon
+	// 1.4.0 (commit 3f2a9c1, built 2025-06-01)", so archived result files
+	// say where they came from. The json-race, CSV and XML reports record it
+	// when set; the json report, a bare array of entries, has no place for it
+	// and leaves it out. It is set by the program, not read from
+	// configuration files.
+	Generator string `json:"-" yaml:"-" toml:"-"`
+}
+
+// Speed units for Configuration.SpeedUnit.
+const (
+	SpeedUnitMetersPerSecond   = "m/s"
+	SpeedUnitKilometersPerHour = "km/h"
+	SpeedUnitPace              = "min/km" // minutes and seconds per kilometre
+)
+
+// ConfigFormat is the encoding of a configuration file.
+type ConfigFormat string
+
+const (
+	ConfigJSON ConfigFormat = "json"
+	ConfigYAML ConfigFormat = "yaml"
+	ConfigTOML ConfigFormat = "toml"
+)
+
+// ConfigFormatFromPath detects the configuration format from the file
+// extension: .json, .yaml, .yml or .toml.
+func ConfigFormatFromPath(path string) (ConfigFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ConfigJSON, nil
+	case ".yaml", ".yml":
+		return ConfigYAML, nil
+	case ".toml":
+		return ConfigTOML, nil
+	default:
+		return "", fmt.Errorf("cannot detect configuration format of %s", path)
+	}
+}
+
+// DefaultConfiguration returns the values used for fields a configuration
+// file leaves out. Laps, lapLen and start have no sensible default and stay
+// zero, so validation still rejects a file without them.
+func DefaultConfiguration() Configuration {
+	return Configuration{
+		PenaltyLen:  150,
+		FiringLines: 1,
+		StartDelta:  "00:00:30",
+	}
+}
+
+// ConfigFields tells which fields of a decoded configuration file were not
+// recognised and which were missing and taken from DefaultConfiguration.
+type ConfigFields struct {
+	Unknown   []string
+	Defaulted []string
+}
+
+// DecodeConfiguration reads a configuration in the given format on top of
+// DefaultConfiguration. Fields that Configuration does not know are not an
+// error; their names are returned so the caller can warn about them.
+func DecodeConfiguration(r io.Reader, format ConfigFormat) (Configuration, ConfigFields, error) {
+	config := DefaultConfiguration()
+	var fields ConfigFields
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return config, fields, err
+	}
+
+	var present map[string]any
+	switch format {
+	case ConfigJSON:
+		if err := json.Unmarshal(data, &present); err != nil {
+			return config, fields, err
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return config, fields, err
+		}
+	case ConfigYAML:
+		if err := yaml.Unmarshal(data, &present); err != nil {
+			return config, fields, err
+		}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return config, fields, err
+		}
+	case ConfigTOML:
+		if err := toml.Unmarshal(data, &present); err != nil {
+			return config, fields, err
+		}
+		if err := toml.Unmarshal(data, &config); err != nil {
+			return config, fields, err
+		}
+	default:
+		return config, fields, fmt.Errorf("unknown configuration format: %s", format)
+	}
+
+	fields.Defaulted = defaultedFields(func(name string) bool {
+		_, ok := present[name]
+		return ok
+	})
+
+	known := make(map[string]bool)
+	configType := reflect.TypeOf(config)
+	for i := 0; i < configType.NumField(); i++ {
+		known[configFieldName(configType.Field(i))] = true
+	}
+
+	for name := range present {
+		if !known[name] {
+			fields.Unknown = append(fields.Unknown, name)
+		}
+	}
+	sort.Strings(fields.Unknown)
+
+	return config, fields, nil
+}
+
+// defaultedFields returns the names of the fields that were not set according
+// to isSet and have a non-zero value in DefaultConfiguration.
+func defaultedFields(isSet func(name string) bool) []string {
+	var defaulted []string
+	configType := reflect.TypeOf(Configuration{})
+	defaults := reflect.ValueOf(DefaultConfiguration())
+	for i := 0; i < configType.NumField(); i++ {
+		name := configFieldName(configType.Field(i))
+		if !isSet(name) && !defaults.Field(i).IsZero() {
+			defaulted = append(defaulted, name)
+		}
+	}
+
+	return defaulted
+}
+
+// configFieldName returns the name of a Configuration field in files.
+func configFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
+
+// LapLength returns the length of the given 0-based lap: its LapLens entry
+// when LapLens covers every lap, LapLen otherwise.
+func (config Configuration) LapLength(lap int) int {
+	if len(config.LapLens) == config.Laps && lap >= 0 && lap < len(config.LapLens) {
+		return config.LapLens[lap]
+	}
+
+	return config.LapLen
+}
+
+// defaultTargetsPerLine is the number of targets on a standard firing line.
+const defaultTargetsPerLine = 5
+
+// TargetsPerLineOrDefault returns TargetsPerLine, or the standard five targets
+// if it is not set.
+func (config Configuration) TargetsPerLineOrDefault() int {
+	if config.TargetsPerLine > 0 {
+		return config.TargetsPerLine
+	}
+
+	return defaultTargetsPerLine
+}
+
+// defaultNationScoreCount is the number of finishers scoring for a nation.
+const defaultNationScoreCount = 3
+
+// NationScoreCountOrDefault returns NationScoreCount, or three if it is not
+// set.
+func (config Configuration) NationScoreCountOrDefault() int {
+	if config.NationScoreCount > 0 {
+		return config.NationScoreCount
+	}
+
+	return defaultNationScoreCount
+}
+
+// FormatSpeed formats a speed in m/s in the configured unit and precision.
+// Paces are written as "mm:ss" per kilometre; a zero speed has no pace.
+func (config Configuration) FormatSpeed(speed float64) string {
+	decimals := 3
+	if config.SpeedDecimals != nil {
+		decimals = *config.SpeedDecimals
+	}
+
+	switch config.SpeedUnit {
+	case SpeedUnitKilometersPerHour:
+		return strconv.FormatFloat(speed*3.6, 'f', decimals, 64)
+	case SpeedUnitPace:
+		if speed <= 0 {
+			return ""
+		}
+		scale := math.Pow10(decimals)
+		pace := math.Round(1000/speed*scale) / scale
+		minutes := math.Floor(pace / 60)
+		width := 2 // the seconds, and the point and decimals if any
+		if decimals > 0 {
+			width += 1 + decimals
+		}
+		return fmt.Sprintf("%02d:%0*.*f", int(minutes), width, decimals, pace-minutes*60)
+	default:
+		return strconv.FormatFloat(speed, 'f', decimals, 64)
+	}
+}
+
+// PenaltyLoopsPerMissOrDefault returns PenaltyLoopsPerMiss, or one loop per
+// miss if it is not set.
+func (config Configuration) PenaltyLoopsPerMissOrDefault() int {
+	if config.PenaltyLoopsPerMiss > 0 {
+		return config.PenaltyLoopsPerMiss
+	}
+
+	return 1
+}
+
+// parseDuration parses an "HH:MM:SS" duration with optional fractional seconds.
+func parseDuration(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04:05", s)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)), nil
+}
+
+// ValidateConfiguration is a shorthand for config.Validate().
+func ValidateConfiguration(config Configuration) error {
+	return config.Validate()
+}
+
+// Validate checks every field of the configuration and returns all problems
+// found joined into a single error, or nil if the configuration is usable.
+func (config Configuration) Validate() error {
+	var errs []error
+
+	if config.Laps <= 0 {
+		errs = append(errs, fmt.Errorf("laps must be positive, got %d", config.Laps))
+	}
+	if len(config.LapLens) == 0 && config.LapLen <= 0 {
+		errs = append(errs, fmt.Errorf("lapLen must be positive, got %d", config.LapLen))
+	}
+	if len(config.LapLens) > 0 && len(config.LapLens) != config.Laps {
+		errs = append(errs, fmt.Errorf("lapLens must have one entry per lap, got %d for %d laps", len(config.LapLens), config.Laps))
+	}
+	for i, lapLen := range config.LapLens {
+		if lapLen <= 0 {
+			errs = append(errs, fmt.Errorf("lapLens[%d] must be positive, got %d", i, lapLen))
+		}
+	}
+	if config.PenaltyLen <= 0 {
+		errs = append(errs, fmt.Errorf("penaltyLen must be positive, got %d", config.PenaltyLen))
+	}
+	if config.FiringLines <= 0 {
+		errs = append(errs, fmt.Errorf("firingLines must be positive, got %d", config.FiringLines))
+	}
+	if config.TargetsPerLine < 0 {
+		errs = append(errs, fmt.Errorf("targetsPerLine must not be negative, got %d", config.TargetsPerLine))
+	}
+	if config.PenaltyLoopsPerMiss < 0 {
+		errs = append(errs, fmt.Errorf("penaltyLoopsPerMiss must not be negative, got %d", config.PenaltyLoopsPerMiss))
+	}
+	if config.GracePeriodSeconds < 0 {
+		errs = append(errs, fmt.Errorf("gracePeriodSeconds must not be negative, got %d", config.GracePeriodSeconds))
+	}
+	if config.LapDebounceMillis < 0 {
+		errs = append(errs, fmt.Errorf("lapDebounceMillis must not be negative, got %d", config.LapDebounceMillis))
+	}
+	if config.NationScoreCount < 0 {
+		errs = append(errs, fmt.Errorf("nationScoreCount must not be negative, got %d", config.NationScoreCount))
+	}
+	switch config.SpeedUnit {
+	case "", SpeedUnitMetersPerSecond, SpeedUnitKilometersPerHour, SpeedUnitPace:
+	default:
+		errs = append(errs, fmt.Errorf("speedUnit must be %q, %q or %q, got %q",
+			SpeedUnitMetersPerSecond, SpeedUnitKilometersPerHour, SpeedUnitPace, config.SpeedUnit))
+	}
+	if config.SpeedDecimals != nil && *config.SpeedDecimals < 0 {
+		errs = append(errs, fmt.Errorf("speedDecimals must not be negative, got %d", *config.SpeedDecimals))
+	}
+	if config.Start == "" {
+		errs = append(errs, errors.New("start must not be empty"))
+	} else if _, err := time.Parse("15:04:05.000", config.Start); err != nil {
+		errs = append(errs, fmt.Errorf("invalid start %q: %v", config.Start, err))
+	}
+	if _, err := parseDuration(config.StartDelta); err != nil {
+		errs = append(errs, fmt.Errorf("invalid startDelta %q: %v", config.StartDelta, err))
+	}
+	if config.StartTolerance != "" {
+		if _, err := parseDuration(config.StartTolerance); err != nil {
+			errs = append(errs, fmt.Errorf("invalid startTolerance %q: %v", config.StartTolerance, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
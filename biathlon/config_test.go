@@ -0,0 +1,327 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfiguration() Configuration {
+	return Configuration{
+		Laps:        2,
+		LapLen:      3500,
+		PenaltyLen:  150,
+		FiringLines: 2,
+		Start:       "10:00:00.000",
+		StartDelta:  "00:01:30",
+	}
+}
+
+func TestValidateConfiguration(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*Configuration)
+		expected []string
+	}{
+		{"valid", func(c *Configuration) {}, nil},
+		{"zero laps", func(c *Configuration) { c.Laps = 0 }, []string{"laps"}},
+		{"negative lapLen", func(c *Configuration) { c.LapLen = -1 }, []string{"lapLen"}},
+		{"zero penaltyLen", func(c *Configuration) { c.PenaltyLen = 0 }, []string{"penaltyLen"}},
+		{"zero firingLines", func(c *Configuration) { c.FiringLines = 0 }, []string{"firingLines"}},
+		{"negative gracePeriodSeconds", func(c *Configuration) { c.GracePeriodSeconds = -1 }, []string{"gracePeriodSeconds"}},
+		{"lapLens", func(c *Configuration) { c.LapLens = []int{3500, 3000} }, nil},
+		{"lapLens without lapLen", func(c *Configuration) {
+			c.LapLen = 0
+			c.LapLens = []int{3500, 3000}
+		}, nil},
+		{"too few lapLens", func(c *Configuration) { c.LapLens = []int{3500} }, []string{"lapLens"}},
+		{"too many lapLens", func(c *Configuration) { c.LapLens = []int{3500, 3500, 3000} }, []string{"lapLens"}},
+		{"zero lapLens entry", func(c *Configuration) { c.LapLens = []int{3500, 0} }, []string{"lapLens[1]"}},
+		{"negative targetsPerLine", func(c *Configuration) { c.TargetsPerLine = -1 }, []string{"targetsPerLine"}},
+		{"negative penaltyLoopsPerMiss", func(c *Configuration) { c.PenaltyLoopsPerMiss = -1 }, []string{"penaltyLoopsPerMiss"}},
+		{"negative lapDebounceMillis", func(c *Configuration) { c.LapDebounceMillis = -1 }, []string{"lapDebounceMillis"}},
+		{"unknown speedUnit", func(c *Configuration) { c.SpeedUnit = "mph" }, []string{"speedUnit"}},
+		{"negative speedDecimals", func(c *Configuration) { c.SpeedDecimals = decimals(-1) }, []string{"speedDecimals"}},
+		{"negative nationScoreCount", func(c *Configuration) { c.NationScoreCount = -1 }, []string{"nationScoreCount"}},
+		{"empty start", func(c *Configuration) { c.Start = "" }, []string{"start"}},
+		{"bad start", func(c *Configuration) { c.Start = "10am" }, []string{"start"}},
+		{"bad startDelta", func(c *Configuration) { c.StartDelta = "90s" }, []string{"startDelta"}},
+		{"startTolerance", func(c *Configuration) { c.StartTolerance = "00:00:30.000" }, nil},
+		{"bad startTolerance", func(c *Configuration) { c.StartTolerance = "30s" }, []string{"startTolerance"}},
+		{"empty startDelta", func(c *Configuration) { c.StartDelta = "" }, []string{"startDelta"}},
+		{"several problems", func(c *Configuration) {
+			c.Laps = -2
+			c.PenaltyLen = -5
+			c.StartDelta = "soon"
+		}, []string{"laps", "penaltyLen", "startDelta"}},
+		{"all problems", func(c *Configuration) {
+			*c = Configuration{GracePeriodSeconds: -1}
+		}, []string{"laps", "lapLen", "penaltyLen", "firingLines", "gracePeriodSeconds", "start", "startDelta"}},
+	}
+
+	for _, test := range tests {
+		config := validConfiguration()
+		test.modify(&config)
+
+		err := config.Validate()
+		if len(test.expected) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+
+		if err == nil {
+			t.Errorf("%s: expected error, got none", test.name)
+			continue
+		}
+
+		lines := strings.Split(err.Error(), "\n")
+		if len(lines) != len(test.expected) {
+			t.Errorf("%s: expected %d problems, got %d: %v", test.name, len(test.expected), len(lines), err)
+			continue
+		}
+
+		if wrapped := ValidateConfiguration(config); wrapped == nil || wrapped.Error() != err.Error() {
+			t.Errorf("%s: ValidateConfiguration disagrees with Validate: %v", test.name, wrapped)
+		}
+
+		for i, field := range test.expected {
+			if !strings.HasPrefix(lines[i], field+" ") && !strings.Contains(lines[i], " "+field+" ") {
+				t.Errorf("%s: expected problem %d to mention %s, got %q", test.name, i, field, lines[i])
+			}
+		}
+	}
+}
+
+// decimals returns a SpeedDecimals of n.
+func decimals(n int) *int {
+	return &n
+}
+
+func TestFormatSpeed(t *testing.T) {
+	tests := []struct {
+		unit     string
+		decimals *int
+		speed    float64
+		expected string
+	}{
+		{"", nil, 4.6157, "4.616"},
+		{SpeedUnitMetersPerSecond, decimals(2), 4.6157, "4.62"},
+		{SpeedUnitMetersPerSecond, decimals(0), 4.6157, "5"},
+		{SpeedUnitKilometersPerHour, decimals(1), 5, "18.0"},
+		{SpeedUnitKilometersPerHour, nil, 4.6157, "16.617"},
+		{SpeedUnitKilometersPerHour, decimals(0), 4.6157, "17"},
+		{SpeedUnitPace, nil, 4, "04:10.000"},
+		{SpeedUnitPace, decimals(1), 1000.0 / 119.96, "02:00.0"},
+		{SpeedUnitPace, decimals(0), 1000.0 / 119.96, "02:00"},
+		{SpeedUnitPace, nil, 0, ""},
+	}
+
+	for i, test := range tests {
+		config := Configuration{SpeedUnit: test.unit, SpeedDecimals: test.decimals}
+		if got := config.FormatSpeed(test.speed); got != test.expected {
+			t.Errorf("Test %d: expected %q for %v m/s, got %q", i, test.expected, test.speed, got)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		hasError bool
+	}{
+		{"00:01:30", 90 * time.Second, false},
+		{"00:00:30.500", 30*time.Second + 500*time.Millisecond, false},
+		{"01:00:00", time.Hour, false},
+		{"1m30s", 0, true},
+	}
+
+	for _, test := range tests {
+		result, err := parseDuration(test.input)
+		if test.hasError {
+			if err == nil {
+				t.Errorf("Expected error for input %s, but got none", test.input)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error for input %s: %v", test.input, err)
+			continue
+		}
+
+		if result != test.expected {
+			t.Errorf("For input %s, expected %v, got %v", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestDecodeConfigurationFormats(t *testing.T) {
+	jsonConfig := `{
+    "laps": 2,
+    "lapLen": 3500,
+    "penaltyLen": 150,
+    "firingLines": 2,
+    "start": "10:00:00.000",
+    "startDelta": "00:01:30",
+    "venue": "Östersund"
+}`
+
+	yamlConfig := `laps: 2
+lapLen: 3500
+penaltyLen: 150
+firingLines: 2
+start: "10:00:00.000"
+startDelta: 00:01:30
+venue: Östersund
+`
+
+	tomlConfig := `laps = 2
+lapLen = 3500
+penaltyLen = 150
+firingLines = 2
+start = "10:00:00.000"
+startDelta = "00:01:30"
+venue = "Östersund"
+`
+
+	fromJSON, fieldsJSON, err := DecodeConfiguration(strings.NewReader(jsonConfig), ConfigJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding JSON: %v", err)
+	}
+
+	fromYAML, fieldsYAML, err := DecodeConfiguration(strings.NewReader(yamlConfig), ConfigYAML)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding YAML: %v", err)
+	}
+
+	fromTOML, fieldsTOML, err := DecodeConfiguration(strings.NewReader(tomlConfig), ConfigTOML)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding TOML: %v", err)
+	}
+
+	if !reflect.DeepEqual(fromJSON, validConfiguration()) || !reflect.DeepEqual(fromYAML, fromJSON) || !reflect.DeepEqual(fromTOML, fromJSON) {
+		t.Errorf("Expected identical configurations, got JSON %+v, YAML %+v and TOML %+v", fromJSON, fromYAML, fromTOML)
+	}
+
+	for _, fields := range []ConfigFields{fieldsJSON, fieldsYAML, fieldsTOML} {
+		if !reflect.DeepEqual(fields.Unknown, []string{"venue"}) || len(fields.Defaulted) != 0 {
+			t.Errorf("Expected unknown field venue and no defaults, got %+v", fields)
+		}
+	}
+
+	outputs := make([]string, 0, 3)
+	for _, config := range []Configuration{fromJSON, fromYAML, fromTOML} {
+		eventsFile, err := os.Open("../sunny_5_skiers/events")
+		if err != nil {
+			t.Fatalf("Unexpected error opening sample events: %v", err)
+		}
+
+		events, err := ReadEvents(context.Background(), eventsFile)
+		eventsFile.Close()
+		if err != nil {
+			t.Fatalf("Unexpected error reading sample events: %v", err)
+		}
+
+		var out bytes.Buffer
+		competitors, _, _, err := ProcessEvents(context.Background(), events, config,
+			WithLogger(narrationLogger(&out)), WithOutgoing(&out))
+		if err != nil {
+			t.Fatalf("Unexpected error processing sample events: %v", err)
+		}
+
+		if err := WriteReport(&out, competitors, config, FormatJSON); err != nil {
+			t.Fatalf("Unexpected error writing report: %v", err)
+		}
+		outputs = append(outputs, out.String())
+	}
+
+	for i, format := range []ConfigFormat{ConfigYAML, ConfigTOML} {
+		if outputs[i+1] != outputs[0] {
+			t.Errorf("Expected identical output for JSON and %s configurations:\n%s\n---\n%s", format, outputs[0], outputs[i+1])
+		}
+	}
+}
+
+func TestConfigFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected ConfigFormat
+		hasError bool
+	}{
+		{"config.json", ConfigJSON, false},
+		{"race/config.yaml", ConfigYAML, false},
+		{"config.YML", ConfigYAML, false},
+		{"config.toml", ConfigTOML, false},
+		{"config.ini", "", true},
+	}
+
+	for _, test := range tests {
+		result, err := ConfigFormatFromPath(test.path)
+		if test.hasError {
+			if err == nil {
+				t.Errorf("Expected error for path %s, but got none", test.path)
+			}
+			continue
+		}
+
+		if err != nil || result != test.expected {
+			t.Errorf("For path %s, expected %s, got %s (%v)", test.path, test.expected, result, err)
+		}
+	}
+}
+
+func TestDecodeConfigurationDefaults(t *testing.T) {
+	minimal := `{"laps": 2, "lapLen": 3500, "start": "10:00:00.000"}`
+
+	config, fields, err := DecodeConfiguration(strings.NewReader(minimal), ConfigJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := DefaultConfiguration()
+	expected.Laps = 2
+	expected.LapLen = 3500
+	expected.Start = "10:00:00.000"
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, config)
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected minimal configuration to be valid, got %v", err)
+	}
+
+	if !reflect.DeepEqual(fields.Defaulted, []string{"penaltyLen", "firingLines", "startDelta"}) {
+		t.Errorf("Unexpected defaulted fields: %v", fields.Defaulted)
+	}
+
+	fromTOML, tomlFields, err := DecodeConfiguration(strings.NewReader("laps = 2\nlapLen = 3500\nstart = \"10:00:00.000\"\n"), ConfigTOML)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding TOML: %v", err)
+	}
+	if !reflect.DeepEqual(fromTOML, config) || !reflect.DeepEqual(tomlFields, fields) {
+		t.Errorf("Expected TOML defaults to match JSON, got %+v (%+v)", fromTOML, tomlFields)
+	}
+
+	config, _, err = DecodeConfiguration(strings.NewReader(`{"penaltyLen": 100}`), ConfigJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err = config.Validate()
+	if err == nil {
+		t.Fatal("Expected missing required fields to be rejected")
+	}
+
+	for _, field := range []string{"laps", "lapLen", "start"} {
+		if !strings.Contains(err.Error(), field+" ") {
+			t.Errorf("Expected error about %s, got %v", field, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package biathlon
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Correction records an official time correction (event 14) of one of the
+// competitor's earlier events.
+type Correction struct {
+	EventID       int // the corrected event
+	OriginalTime  time.Time
+	CorrectedTime time.Time
+	Reason        string
+	At            time.Time // when the correction was issued
+}
+
+// correctEvent handles event 14, "<originalEventID> <HH:MM:SS.sss> <reason>".
+// The competitor's latest event with that ID gets the new time and all their
+// events are applied again, so lap and penalty times are derived from the
+// corrected time. Commentary and outgoing events are not repeated.
+func (p *Processor) correctEvent(competitor *Competitor, event EventLog) error {
+	params := strings.SplitN(event.ExtraParams, " ", 3)
+	if len(params) < 2 {
+		return fmt.Errorf("invalid correction %q, want \"<eventID> <time> <reason>\"", event.ExtraParams)
+	}
+	eventID, err := strconv.Atoi(params[0])
+	if err != nil {
+		return fmt.Errorf("invalid corrected event %q: %w", params[0], err)
+	}
+	correctedTime, err := parseTime(params[1])
+	if err != nil {
+		return fmt.Errorf("invalid corrected time %q: %w", params[1], err)
+	}
+	correctedTime = nearestDay(correctedTime, event.Time)
+	reason := ""
+	if len(params) == 3 {
+		reason = params[2]
+	}
+
+	history := slices.Clone(p.history[competitor.ID])
+	index := -1
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].EventID == eventID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("%s has no event %d to correct", competitor.Label(), eventID)
+	}
+
+	correction := Correction{
+		EventID:       eventID,
+		OriginalTime:  history[index].Time,
+		CorrectedTime: correctedTime,
+		Reason:        reason,
+		At:            event.Time,
+	}
+	history[index].Time = correctedTime
+
+	rebuilt, err := p.rebuildCompetitor(competitor, history)
+	if err != nil {
+		return fmt.Errorf("correcting event %d: %w", eventID, err)
+	}
+	rebuilt.Corrections = append(competitor.Corrections, correction)
+	*competitor = *rebuilt
+	p.history[competitor.ID] = history
+	p.resetBestSplits()
+
+	p.logf(slog.LevelInfo, event, "The time of event %d for the %s was corrected from %s to %s: %s",
+		eventID, competitor.Label(), formatTime(correction.OriginalTime), formatTime(correctedTime), reason)
+
+	return nil
+}
+
+// rebuildCompetitor applies history, which starts with the registration, to a
+// fresh copy of competitor. The events after the registration are sorted by
+// time first, as a correction may have moved one past another. The competitor
+// itself is left untouched.
+func (p *Processor) rebuildCompetitor(competitor *Competitor, history []EventLog) (*Competitor, error) {
+	slices.SortStableFunc(history[1:], func(a, b EventLog) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	rebuilt := newCompetitor(competitor.ID, competitor.Name, history[0].Time)
+	rebuilt.PlannedStartTime = competitor.PlannedStartTime
+	rebuilt.Nation, rebuilt.Bib = competitor.Nation, competitor.Bib
+
+	// The replayed splits are compared with the other competitors' on a copy
+	// of the fastest splits, which the correction then derives again
+	p.competitors[competitor.ID] = rebuilt
+	bestSplits := p.bestSplits
+	p.bestSplits = make(map[splitKey]time.Duration)
+	p.resetBestSplits()
+	p.replaying = true
+	defer func() {
+		p.competitors[competitor.ID] = competitor
+		p.bestSplits = bestSplits
+		p.replaying = false
+	}()
+
+	for _, event := range history[1:] {
+		if err := p.applyCompetitorEvent(event); err != nil && !errors.Is(err, errIgnored) {
+			return nil, &EventError{Event: event, Err: err}
+		}
+	}
+
+	return rebuilt, nil
+}
+
+// resetBestSplits derives the fastest split at every checkpoint from the
+// competitors' splits again, after a correction changed some of them.
+func (p *Processor) resetBestSplits() {
+	clear(p.bestSplits)
+	for _, competitor := range p.competitors {
+		for _, split := range competitor.Splits {
+			key := splitKey{lap: split.Lap, checkpoint: split.CheckpointID}
+			if best, ok := p.bestSplits[key]; !ok || split.Elapsed < best {
+				p.bestSplits[key] = split.Elapsed
+			}
+		}
+	}
+}
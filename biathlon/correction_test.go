@@ -0,0 +1,165 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessEventsCorrection(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3000, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:00:00.000] 1 1",
+		"[09:59:00.000] 2 1 10:00:00.000",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 8 1",
+		"[10:06:00.000] 9 1",
+		"[10:10:00.000] 10 1",
+		"[10:20:00.000] 10 1",
+		"[10:30:00.000] 14 1 9 10:05:30.000 photo finish of the penalty loop exit",
+		"[10:31:00.000] 14 1 10 10:19:00.000 transponder delay",
+	})
+
+	var outgoing bytes.Buffer
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config,
+		WithMode(Strict), WithOutgoing(&outgoing))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	competitor := competitors[1]
+	if competitor.Status != "Finished" {
+		t.Fatalf("Expected Finished, got %s", competitor.Status)
+	}
+	if competitor.TotalPenaltyTime != 30*time.Second {
+		t.Errorf("Expected corrected penalty time 30s, got %s", competitor.TotalPenaltyTime)
+	}
+	expectedLaps := []time.Duration{10 * time.Minute, 9 * time.Minute}
+	for i, lapTime := range competitor.LapTimes {
+		if lapTime != expectedLaps[i] {
+			t.Errorf("Expected lap %d time %s, got %s", i+1, expectedLaps[i], lapTime)
+		}
+	}
+	if got := formatTime(competitor.FinishTime); got != "10:19:00.000" {
+		t.Errorf("Expected corrected finish time 10:19:00.000, got %s", got)
+	}
+
+	if len(competitor.Corrections) != 2 {
+		t.Fatalf("Expected 2 corrections, got %+v", competitor.Corrections)
+	}
+	correction := competitor.Corrections[1]
+	if correction.EventID != 10 || formatTime(correction.OriginalTime) != "10:20:00.000" ||
+		correction.Reason != "transponder delay" {
+		t.Errorf("Unexpected correction %+v", correction)
+	}
+
+	// The finish is announced once, at the time it was first reported
+	if outgoing.String() != "[10:20:00.000] 33 1\n" {
+		t.Errorf("Expected a single outgoing finish event, got %q", outgoing.String())
+	}
+
+	var report bytes.Buffer
+	if err := WriteReport(&report, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing text report: %v", err)
+	}
+	for _, want := range []string{
+		"[00:19:00.000] 1* ",
+		"\nCorrections:\n1*: event 9 at 10:06:00.000 corrected to 10:05:30.000 at 10:30:00.000 (photo finish of the penalty loop exit)\n" +
+			"1*: event 10 at 10:20:00.000 corrected to 10:19:00.000 at 10:31:00.000 (transponder delay)\n",
+	} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("Expected text report to contain %q, got %q", want, report.String())
+		}
+	}
+}
+
+func TestProcessorRejectsInvalidCorrections(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3000, PenaltyLen: 150, GracePeriodSeconds: 60}
+
+	tests := []struct {
+		name  string
+		event string
+	}{
+		{"missing time", "[10:30:00.000] 14 1 10"},
+		{"invalid event ID", "[10:30:00.000] 14 1 ten 10:19:00.000 typo"},
+		{"invalid time", "[10:30:00.000] 14 1 4 25:00:00.000 typo"},
+		{"event never seen", "[10:30:00.000] 14 1 10 10:19:00.000 typo"},
+		{"resumed outside the grace period", "[10:30:00.000] 14 1 11 10:04:00.000 typo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProcessor(config)
+			for _, event := range parseEvents(t, []string{
+				"[09:00:00.000] 1 1",
+				"[10:00:00.000] 4 1",
+				"[10:05:00.000] 8 1",
+				"[10:06:00.000] 11 1 Broken ski",
+				"[10:06:30.000] 12 1 Ski replaced",
+			}) {
+				if err := p.AddEvent(event); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			err := p.AddEvent(parseEvents(t, []string{tt.event})[0])
+			var eventErr *EventError
+			if !errors.As(err, &eventErr) {
+				t.Fatalf("Expected *EventError, got %v", err)
+			}
+
+			competitor := p.Results()[1]
+			if len(competitor.Corrections) != 0 || !competitor.Resumed || len(competitor.PenaltyStartTimes) != 1 {
+				t.Errorf("Expected the competitor to be left untouched, got %+v", competitor)
+			}
+		})
+	}
+}
+
+func TestProcessEventsCorrectionReplay(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3000, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:00:00.000] 1 1",
+		"[09:00:01.000] 1 2",
+		"[09:00:02.000] 1 3",
+		"[10:00:00.000] 4 1",
+		"[10:00:00.000] 4 2",
+		"[10:00:00.000] 4 3",
+		"[10:04:00.000] 13 1 1",
+		"[10:05:00.000] 13 2 1",
+		"[10:06:00.000] 14 1 13 10:06:00.000 transponder delay",
+		"[10:07:00.000] 13 3 1 10:05:30.000",
+		"[10:10:00.000] 10 1",
+		"[10:12:00.000] 13 1 2",
+		"[10:14:00.000] 14 1 10 10:13:00.000 lap end missed",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config,
+		WithMode(Strict), WithStateValidation(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The corrected lap end comes after the checkpoint, which is on lap 1 then.
+	// The first split no longer counts as the fastest for competitor 3.
+	start := competitors[1].ActualStartTime
+	expected := []SplitTime{
+		{CheckpointID: 1, Lap: 1, At: start.Add(6 * time.Minute), Elapsed: 6 * time.Minute, Gap: time.Minute},
+		{CheckpointID: 2, Lap: 1, At: start.Add(12 * time.Minute), Elapsed: 12 * time.Minute},
+	}
+	if !reflect.DeepEqual(competitors[1].Splits, expected) {
+		t.Errorf("Expected splits %+v, got %+v", expected, competitors[1].Splits)
+	}
+	if !reflect.DeepEqual(competitors[1].LapTimes, []time.Duration{13 * time.Minute}) {
+		t.Errorf("Expected lap times [13m0s], got %v", competitors[1].LapTimes)
+	}
+	if gap := competitors[3].Splits[0].Gap; gap != 30*time.Second {
+		t.Errorf("Expected competitor 3 to be 30s behind competitor 2, got %s", gap)
+	}
+}
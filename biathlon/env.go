@@ -0,0 +1,149 @@
+package biathlon
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadConfiguration reads the configuration file at path, detecting its format
+// from the extension unless format is set. If path is empty or the file does
+// not exist, the configuration is built from the RACE_* environment variables
+// instead (RACE_LAPS, RACE_LAP_LEN, RACE_START, RACE_START_DELTA, ...) on top
+// of DefaultConfiguration; RACE_LAPS, RACE_LAP_LEN and RACE_START are required.
+func LoadConfiguration(path string, format ConfigFormat) (Configuration, ConfigFields, error) {
+	if path != "" {
+		file, err := os.Open(path)
+		if err == nil {
+			defer file.Close()
+
+			if format == "" {
+				if format, err = ConfigFormatFromPath(path); err != nil {
+					return Configuration{}, ConfigFields{}, err
+				}
+			}
+			return DecodeConfiguration(file, format)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return Configuration{}, ConfigFields{}, err
+		}
+	}
+
+	config := DefaultConfiguration()
+	set, err := config.applyEnv("RACE_")
+	if err != nil {
+		return config, ConfigFields{}, err
+	}
+
+	var missing []string
+	for _, required := range []struct{ json, env string }{
+		{"laps", "RACE_LAPS"},
+		{"lapLen", "RACE_LAP_LEN"},
+		{"start", "RACE_START"},
+	} {
+		if !slices.Contains(set, required.json) {
+			missing = append(missing, required.env)
+		}
+	}
+	if len(missing) > 0 {
+		source := "no configuration file given"
+		if path != "" {
+			source = fmt.Sprintf("configuration file %s not found", path)
+		}
+		return config, ConfigFields{}, fmt.Errorf("%s and %s not set in the environment", source, strings.Join(missing, ", "))
+	}
+
+	fields := ConfigFields{Defaulted: defaultedFields(func(name string) bool {
+		return slices.Contains(set, name)
+	})}
+
+	return config, fields, nil
+}
+
+// ApplyEnv overrides configuration fields with the BIATHLON_* environment
+// variables that are set, e.g. BIATHLON_LAPS or BIATHLON_START_DELTA. It is
+// meant to run after the configuration file is decoded and before it is
+// validated, so the environment takes precedence over the file. Invalid values
+// are reported together, each naming its variable.
+func (config *Configuration) ApplyEnv() error {
+	_, err := config.applyEnv("BIATHLON_")
+	return err
+}
+
+// applyEnv overrides configuration fields with the environment variables named
+// envPrefix followed by the field name in upper snake case, and returns the
+// names of the fields that were set.
+func (config *Configuration) applyEnv(envPrefix string) ([]string, error) {
+	intFields := []struct {
+		name  string
+		json  string
+		field *int
+	}{
+		{"LAPS", "laps", &config.Laps},
+		{"LAP_LEN", "lapLen", &config.LapLen},
+		{"PENALTY_LEN", "penaltyLen", &config.PenaltyLen},
+		{"FIRING_LINES", "firingLines", &config.FiringLines},
+		{"TARGETS_PER_LINE", "targetsPerLine", &config.TargetsPerLine},
+		{"PENALTY_LOOPS_PER_MISS", "penaltyLoopsPerMiss", &config.PenaltyLoopsPerMiss},
+		{"GRACE_PERIOD_SECONDS", "gracePeriodSeconds", &config.GracePeriodSeconds},
+		{"LAP_DEBOUNCE_MILLIS", "lapDebounceMillis", &config.LapDebounceMillis},
+	}
+
+	timeFields := []struct {
+		name  string
+		json  string
+		field *string
+		parse func(string) error
+	}{
+		{"START", "start", &config.Start, func(s string) error {
+			_, err := time.Parse("15:04:05.000", s)
+			return err
+		}},
+		{"START_DELTA", "startDelta", &config.StartDelta, func(s string) error {
+			_, err := parseDuration(s)
+			return err
+		}},
+		{"START_TOLERANCE", "startTolerance", &config.StartTolerance, func(s string) error {
+			_, err := parseDuration(s)
+			return err
+		}},
+	}
+
+	var set []string
+	var errs []error
+	for _, f := range intFields {
+		value, ok := os.LookupEnv(envPrefix + f.name)
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s%s: invalid integer %q", envPrefix, f.name, value))
+			continue
+		}
+		*f.field = n
+		set = append(set, f.json)
+	}
+
+	for _, f := range timeFields {
+		value, ok := os.LookupEnv(envPrefix + f.name)
+		if !ok {
+			continue
+		}
+
+		if err := f.parse(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s%s: invalid time %q: %v", envPrefix, f.name, value, err))
+			continue
+		}
+		*f.field = value
+		set = append(set, f.json)
+	}
+
+	return set, errors.Join(errs...)
+}
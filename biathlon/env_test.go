@@ -0,0 +1,114 @@
+package biathlon
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigurationApplyEnv(t *testing.T) {
+	t.Setenv("BIATHLON_LAPS", "3")
+	t.Setenv("BIATHLON_LAP_LEN", "4000")
+	t.Setenv("BIATHLON_START", "11:30:00.000")
+	t.Setenv("BIATHLON_START_DELTA", "00:00:30")
+
+	config := validConfiguration()
+	if err := config.ApplyEnv(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.Laps != 3 || config.LapLen != 4000 || config.Start != "11:30:00.000" || config.StartDelta != "00:00:30" {
+		t.Errorf("Expected overridden configuration, got %+v", config)
+	}
+
+	if config.PenaltyLen != 150 || config.FiringLines != 2 {
+		t.Errorf("Expected fields without variables to keep their file values, got %+v", config)
+	}
+}
+
+func TestConfigurationApplyEnvInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"BIATHLON_LAPS", "two"},
+		{"BIATHLON_PENALTY_LEN", "150m"},
+		{"BIATHLON_START", "10:00"},
+		{"BIATHLON_START_DELTA", "90s"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(test.name, test.value)
+
+			config := validConfiguration()
+			err := config.ApplyEnv()
+			if err == nil {
+				t.Fatalf("Expected error for %s=%s, got none", test.name, test.value)
+			}
+
+			if !strings.HasPrefix(err.Error(), test.name+": ") {
+				t.Errorf("Expected error to name %s, got %v", test.name, err)
+			}
+
+			if !reflect.DeepEqual(config, validConfiguration()) {
+				t.Errorf("Expected invalid value to be ignored, got %+v", config)
+			}
+		})
+	}
+}
+
+func TestLoadConfigurationFromEnv(t *testing.T) {
+	t.Setenv("RACE_LAPS", "2")
+	t.Setenv("RACE_LAP_LEN", "3500")
+	t.Setenv("RACE_START", "10:00:00.000")
+	t.Setenv("RACE_START_DELTA", "00:01:30")
+
+	for _, path := range []string{"", filepath.Join(t.TempDir(), "missing.json")} {
+		config, fields, err := LoadConfiguration(path, "")
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", path, err)
+		}
+
+		expected := DefaultConfiguration()
+		expected.Laps = 2
+		expected.LapLen = 3500
+		expected.Start = "10:00:00.000"
+		expected.StartDelta = "00:01:30"
+		if !reflect.DeepEqual(config, expected) {
+			t.Errorf("%q: expected %+v, got %+v", path, expected, config)
+		}
+
+		if !reflect.DeepEqual(fields.Defaulted, []string{"penaltyLen", "firingLines"}) {
+			t.Errorf("%q: unexpected defaulted fields: %v", path, fields.Defaulted)
+		}
+	}
+
+	config, _, err := LoadConfiguration("../sunny_5_skiers/config.json", "")
+	if err != nil {
+		t.Fatalf("Unexpected error loading the sample configuration: %v", err)
+	}
+	if config.LapLen != 3500 || config.Start != "10:00:00.000" || config.FiringLines != 2 {
+		t.Errorf("Expected the file to win over RACE_* variables, got %+v", config)
+	}
+}
+
+func TestLoadConfigurationFromEnvMissing(t *testing.T) {
+	t.Setenv("RACE_LAP_LEN", "3500")
+
+	_, _, err := LoadConfiguration("missing.json", "")
+	if err == nil {
+		t.Fatal("Expected error for missing required variables, got none")
+	}
+
+	expected := "configuration file missing.json not found and RACE_LAPS, RACE_START not set in the environment"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+
+	t.Setenv("RACE_LAPS", "many")
+	if _, _, err := LoadConfiguration("", ""); err == nil || !strings.HasPrefix(err.Error(), "RACE_LAPS: ") {
+		t.Errorf("Expected error naming RACE_LAPS, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package biathlon
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EventLog struct {
+	Time         time.Time
+	EventID      int
+	CompetitorID int
+	ExtraParams  string
+}
+
+// Outgoing event IDs generated during processing.
+const (
+	EventDisqualified = 32
+	EventFinished     = 33
+)
+
+// OutgoingEvent is an event generated by the processor rather than read from the log.
+type OutgoingEvent struct {
+	Time         time.Time
+	EventID      int
+	CompetitorID int
+	ExtraParams  string
+}
+
+// String formats the event the same way incoming events are written.
+func (e OutgoingEvent) String() string {
+	line := fmt.Sprintf("[%s] %d %d", formatTime(e.Time), e.EventID, e.CompetitorID)
+	if e.ExtraParams != "" {
+		line += " " + e.ExtraParams
+	}
+
+	return line
+}
+
+// LineError reports a malformed line in an events stream.
+type LineError struct {
+	Source string // the file of the line, if known
+	Line   int
+	Err    error
+}
+
+func (e *LineError) Error() string {
+	if e.Source != "" {
+		return fmt.Sprintf("%s: line %d: %v", e.Source, e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// parseTime parses an HH:MM:SS.sss time, with or without the square brackets
+// of the events file around it.
+func parseTime(timeStr string) (time.Time, error) {
+	bare, opened := strings.CutPrefix(timeStr, "[")
+	bare, closed := strings.CutSuffix(bare, "]")
+	if opened != closed {
+		return time.Time{}, fmt.Errorf("mismatched square brackets: %s", timeStr)
+	}
+
+	return time.Parse("15:04:05.000", bare)
+}
+
+// nearestDay moves t by whole days so it is at most 12 hours from ref. Event
+// times carry no date, so this lets a race cross midnight: 00:20 following
+// 23:50 is on the next day. A zero ref leaves t unchanged.
+func nearestDay(t, ref time.Time) time.Time {
+	if ref.IsZero() {
+		return t
+	}
+	t = onDate(t, ref)
+	if t.Sub(ref) > 12*time.Hour {
+		t = t.AddDate(0, 0, -1)
+	} else if t.Sub(ref) < -12*time.Hour {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t
+}
+
+// onDate returns the time of day of t on the date of day.
+func onDate(t, day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), day.Location())
+}
+
+func formatTime(t time.Time) string {
+	return t.Format("15:04:05.000")
+}
+
+func formatDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	milliseconds := int(d.Milliseconds()) % 1000
+
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, milliseconds)
+}
+
+// formatMinutes formats a duration as "MM:SS.sss", e.g. a gap to the winner.
+// The minutes go past 59 rather than adding hours.
+func formatMinutes(d time.Duration) string {
+	minutes := int(d.Minutes())
+	seconds := int(d.Seconds()) % 60
+	milliseconds := int(d.Milliseconds()) % 1000
+
+	return fmt.Sprintf("%02d:%02d.%03d", minutes, seconds, milliseconds)
+}
+
+// ParseEventLog parses a single "[HH:MM:SS.sss] eventID competitorID extraParams" line.
+func ParseEventLog(line string) (EventLog, error) {
+	parts := strings.SplitN(line, "] ", 2)
+	if len(parts) < 2 {
+		return EventLog{}, fmt.Errorf("invalid event log format: %s", line)
+	}
+
+	timeStr := parts[0] + "]"
+	eventTime, err := parseTime(timeStr)
+	if err != nil {
+		return EventLog{}, fmt.Errorf("invalid time format: %s", err)
+	}
+
+	eventText := parts[1]
+	fields := strings.Fields(eventText)
+	if len(fields) < 1 {
+		return EventLog{}, fmt.Errorf("invalid event format: %s", eventText)
+	}
+
+	eventID, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return EventLog{}, fmt.Errorf("invalid event ID: %s", fields[0])
+	}
+
+	// The start gun concerns no competitor, so the ID may be left out
+	competitorID := 0
+	if len(fields) > 1 {
+		competitorID, err = strconv.Atoi(fields[1])
+		if err != nil {
+			return EventLog{}, fmt.Errorf("invalid competitor ID: %s", fields[1])
+		}
+	} else if eventID != 17 {
+		return EventLog{}, fmt.Errorf("invalid event format: %s", eventText)
+	}
+
+	extraParams := ""
+	if len(fields) > 2 {
+		extraParams = strings.Join(fields[2:], " ")
+	}
+
+	return EventLog{
+		Time:         eventTime,
+		EventID:      eventID,
+		CompetitorID: competitorID,
+		ExtraParams:  extraParams,
+	}, nil
+}
+
+// ReadEvents parses one event per non-blank line of r. Malformed lines are
+// skipped and reported together as *LineError values in the returned error,
+// while the well-formed events are still returned. A failure reading r or
+// cancellation of ctx is returned on its own, with the events read so far.
+func ReadEvents(ctx context.Context, r io.Reader) ([]EventLog, error) {
+	scanner := bufio.NewScanner(r)
+
+	var events []EventLog
+	var lineErrs []error
+	lineNum := 0
+	for scanner.Scan() {
+		if lineNum%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return events, err
+			}
+		}
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		event, err := ParseEventLog(line)
+		if err != nil {
+			lineErrs = append(lineErrs, &LineError{Line: lineNum, Err: err})
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return events, err
+	}
+
+	return events, errors.Join(lineErrs...)
+}
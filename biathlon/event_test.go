@@ -0,0 +1,179 @@
+package biathlon
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		hasError bool
+	}{
+		{"[10:00:00.000]", "10:00:00.000", false},
+		{"[09:30:01.005]", "09:30:01.005", false},
+		{"[23:59:59.999]", "23:59:59.999", false},
+		{"10:00:00.000", "10:00:00.000", false},
+		{"23:59:59.999", "23:59:59.999", false},
+		{"[10:00:00]", "", true},
+		{"10:00:00", "", true},
+		{"[10:00:00.000", "", true},
+		{"10:00:00.000]", "", true},
+		{"[[10:00:00.000]]", "", true},
+		{"[ 10:00:00.000 ]", "", true},
+		{"[10:00: 00.000]", "", true},
+		{"10:00:00.000 ", "", true},
+	}
+
+	for _, test := range tests {
+		result, err := parseTime(test.input)
+		if test.hasError {
+			if err == nil {
+				t.Errorf("Expected error for input %s, but got none", test.input)
+			}
+		} else {
+			if err != nil {
+				t.Errorf("Unexpected error for input %s: %v", test.input, err)
+				continue
+			}
+
+			if formatTime(result) != test.expected {
+				t.Errorf("For input %s, expected %s, got %s", test.input, test.expected, formatTime(result))
+			}
+		}
+	}
+}
+
+// parseEventLogTests are the cases of TestParseEventLog, which also seed
+// FuzzParseEventLog.
+var parseEventLogTests = []struct {
+	input         string
+	expectedTime  string
+	expectedEvent int
+	expectedID    int
+	expectedExtra string
+	hasError      bool
+}{
+	{"[09:05:59.867] 1 1", "09:05:59.867", 1, 1, "", false},
+	{"[09:15:00.841] 2 1 09:30:00.000", "09:15:00.841", 2, 1, "09:30:00.000", false},
+	{"[09:59:03.872] 11 1 Lost in the forest", "09:59:03.872", 11, 1, "Lost in the forest", false},
+	{"[10:00:00.000] 17", "10:00:00.000", 17, 0, "", false},
+	{"[10:00:00.000] 17 0", "10:00:00.000", 17, 0, "", false},
+	{"[10:00:00.000] 4", "", 0, 0, "", true},
+	{"Invalid event", "", 0, 0, "", true},
+}
+
+func TestParseEventLog(t *testing.T) {
+	for _, test := range parseEventLogTests {
+		result, err := ParseEventLog(test.input)
+		if test.hasError {
+			if err == nil {
+				t.Errorf("Expected error for input %s, but got none", test.input)
+			}
+		} else {
+			if err != nil {
+				t.Errorf("Unexpected error for input %s: %v", test.input, err)
+				continue
+			}
+
+			if formatTime(result.Time) != test.expectedTime {
+				t.Errorf("For input %s, expected time %s, got %s", test.input, test.expectedTime, formatTime(result.Time))
+			}
+
+			if result.EventID != test.expectedEvent {
+				t.Errorf("For input %s, expected event ID %d, got %d", test.input, test.expectedEvent, result.EventID)
+			}
+
+			if result.CompetitorID != test.expectedID {
+				t.Errorf("For input %s, expected competitor ID %d, got %d", test.input, test.expectedID, result.CompetitorID)
+			}
+
+			if result.ExtraParams != test.expectedExtra {
+				t.Errorf("For input %s, expected extra params %s, got %s", test.input, test.expectedExtra, result.ExtraParams)
+			}
+		}
+	}
+}
+
+// FuzzParseEventLog checks that no line makes ParseEventLog or parseTime
+// panic, and that an accepted line parses the same once formatted again.
+func FuzzParseEventLog(f *testing.F) {
+	for _, test := range parseEventLogTests {
+		f.Add([]byte(test.input))
+	}
+	f.Add([]byte("[09:05:59.867] 11 1 \xff\xfe invalid UTF-8"))
+	f.Add([]byte("[09:05:59.867] 11 1 Lost\nin the forest"))
+	f.Add([]byte("[09:05:59.867\n] 1 1"))
+	f.Add([]byte("[09:05:59.867] 11 1 " + strings.Repeat("a ", 1<<12)))
+	f.Add([]byte("[] 1 1"))
+	f.Add([]byte("[[]]] 1 1"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		line := string(data)
+
+		timeStr, _, _ := strings.Cut(line, "]")
+		parseTime(timeStr + "]")
+
+		event, err := ParseEventLog(line)
+		if err != nil {
+			return
+		}
+
+		formatted := OutgoingEvent(event).String()
+		again, err := ParseEventLog(formatted)
+		if err != nil {
+			t.Fatalf("%q parsed as %q, which fails to parse: %v", line, formatted, err)
+		}
+		if !again.Time.Equal(event.Time) || again.EventID != event.EventID ||
+			again.CompetitorID != event.CompetitorID || again.ExtraParams != event.ExtraParams {
+			t.Errorf("%q parsed as %+v, but %q as %+v", line, event, formatted, again)
+		}
+	})
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{1*time.Hour + 30*time.Minute + 45*time.Second + 500*time.Millisecond, "01:30:45.500"},
+		{45*time.Second + 5*time.Millisecond, "00:00:45.005"},
+		{25*time.Hour + 12*time.Minute + 37*time.Second + 128*time.Millisecond, "25:12:37.128"},
+	}
+
+	for _, test := range tests {
+		result := formatDuration(test.input)
+		if result != test.expected {
+			t.Errorf("For input %v, expected %s, got %s", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestReadEvents(t *testing.T) {
+	input := "[09:05:59.867] 1 1\n" +
+		"\n" +
+		"garbage\n" +
+		"[09:15:00.841] 2 1 09:30:00.000\n"
+
+	events, err := ReadEvents(context.Background(), strings.NewReader(input))
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	if events[1].EventID != 2 || events[1].ExtraParams != "09:30:00.000" {
+		t.Errorf("Unexpected second event: %+v", events[1])
+	}
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) {
+		t.Fatalf("Expected a LineError, got %v", err)
+	}
+
+	if lineErr.Line != 3 {
+		t.Errorf("Expected error on line 3, got line %d", lineErr.Line)
+	}
+}
@@ -0,0 +1,399 @@
+package biathlon
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// startCompetitor handles event 4: the competitor crossed the start line,
+// possibly outside their start window.
+func (p *Processor) startCompetitor(competitor *Competitor, event EventLog) error {
+	if !competitor.ActualStartTime.IsZero() {
+		p.warnf(event, "%s already started at %s", competitor.Label(), formatTime(competitor.ActualStartTime))
+		return errIgnored
+	}
+	if !competitor.PlannedStartTime.IsZero() && event.Time.Before(competitor.PlannedStartTime.Add(-p.startWindow(competitor.ID))) {
+		p.warnf(event, "%s started before the planned start time %s",
+			competitor.Label(), formatTime(competitor.PlannedStartTime))
+	}
+
+	competitor.ActualStartTime = event.Time
+	competitor.CurrentLap = 1
+	competitor.LapStartTimes = append(competitor.LapStartTimes, event.Time)
+	competitor.LapLengths = append(competitor.LapLengths, p.courseAt(event.Time).LapLength(0))
+	competitor.Status = "Started"
+	p.logf(slog.LevelInfo, event, "The %s has started", competitor.Label())
+
+	// Check if competitor started too late (outside their start window)
+	// The start window is the planned start time + the competitor's start
+	// window, which is config.StartDelta unless overridden
+	// Without a planned start time there is no window to judge against
+	if !competitor.PlannedStartTime.IsZero() && event.Time.After(competitor.PlannedStartTime.Add(p.startWindow(competitor.ID))) {
+		competitor.Status = "Disqualified"
+		competitor.DisqualificationReason = fmt.Sprintf("started outside allowed window: planned %s, actual %s",
+			formatTime(competitor.PlannedStartTime), formatTime(event.Time))
+		p.logf(slog.LevelWarn, event, "The %s is disqualified", competitor.Label())
+		p.logf(slog.LevelDebug, event, "The %s is disqualified because they %s",
+			competitor.Label(), competitor.DisqualificationReason)
+		p.emit(event.Time, EventDisqualified, competitor.ID)
+	}
+
+	return nil
+}
+
+// startWithGun handles the event 17 fireStartGun gives each competitor
+// waiting to start. The gun is the official start, so unlike event 4 it is
+// never early or late for the competitor's start window.
+func (p *Processor) startWithGun(competitor *Competitor, event EventLog) error {
+	if !competitor.ActualStartTime.IsZero() {
+		p.warnf(event, "%s already started at %s", competitor.Label(), formatTime(competitor.ActualStartTime))
+		return errIgnored
+	}
+
+	competitor.ActualStartTime = event.Time
+	competitor.CurrentLap = 1
+	competitor.LapStartTimes = append(competitor.LapStartTimes, event.Time)
+	competitor.LapLengths = append(competitor.LapLengths, p.courseAt(event.Time).LapLength(0))
+	competitor.Status = "Started"
+	p.logf(slog.LevelInfo, event, "The %s has started with the start gun", competitor.Label())
+
+	return nil
+}
+
+// enterFiringRange handles event 5. The range must exist on the course, and
+// visiting more ranges than the course has is flagged.
+func (p *Processor) enterFiringRange(competitor *Competitor, event EventLog) error {
+	firingRange, err := strconv.Atoi(event.ExtraParams)
+	if err != nil {
+		return fmt.Errorf("invalid firing range %q: %w", event.ExtraParams, err)
+	}
+	if p.config.FiringLines > 0 {
+		if firingRange < 1 || firingRange > p.config.FiringLines {
+			return fmt.Errorf("firing range %d does not exist, the course has %d", firingRange, p.config.FiringLines)
+		}
+		if len(competitor.RangeVisits) == p.config.FiringLines {
+			p.warnf(event, "%s visited more than %d firing ranges", competitor.Label(), p.config.FiringLines)
+		}
+	}
+
+	p.enterRange(competitor, event)
+	competitor.CurrentFiringRange = firingRange
+	competitor.RangeAccuracy = append(competitor.RangeAccuracy, 0)
+	competitor.RangeVisits = append(competitor.RangeVisits, RangeVisit{
+		Lap:         max(competitor.CurrentLap, 1),
+		FiringRange: firingRange,
+		Enter:       event.Time,
+	})
+	p.logf(slog.LevelInfo, event, "The %s is on the firing range(%s)", competitor.Label(), event.ExtraParams)
+
+	return nil
+}
+
+// hitTarget handles event 6, a shot that hit, and tracks the accuracy of the
+// current firing range visit.
+func (p *Processor) hitTarget(competitor *Competitor, event EventLog) error {
+	if _, err := strconv.Atoi(event.ExtraParams); err != nil {
+		return fmt.Errorf("invalid target %q: %w", event.ExtraParams, err)
+	}
+	competitor.Hits++
+	competitor.addShots(1)
+
+	if visit := len(competitor.RangeVisits) - 1; visit >= 0 {
+		hits := &competitor.RangeVisits[visit].Hits
+		*hits++
+		targets := p.config.TargetsPerLineOrDefault()
+		competitor.RangeAccuracy[visit] = float64(*hits) / float64(targets)
+		if *hits == targets+1 {
+			p.warnf(event, "%s hit more than %d targets on firing range %d",
+				competitor.Label(), targets, competitor.CurrentFiringRange)
+		}
+	}
+	p.logf(slog.LevelInfo, event, "The target(%s) has been hit by %s", event.ExtraParams, competitor.Label())
+
+	return nil
+}
+
+// missTarget handles event 15, a shot that missed.
+func (p *Processor) missTarget(competitor *Competitor, event EventLog) error {
+	if _, err := strconv.Atoi(event.ExtraParams); err != nil {
+		return fmt.Errorf("invalid target %q: %w", event.ExtraParams, err)
+	}
+	competitor.addShots(1)
+
+	if visit := len(competitor.RangeVisits) - 1; visit >= 0 {
+		competitor.RangeVisits[visit].Misses++
+	}
+	p.logf(slog.LevelInfo, event, "The target(%s) has been missed by %s", event.ExtraParams, competitor.Label())
+
+	return nil
+}
+
+// leaveFiringRange handles event 7. Every bout is a full set of shots, so the
+// targets neither hit (event 6) nor missed (event 15) are misses as well, each
+// owing penaltyLoopsPerMiss loops.
+func (p *Processor) leaveFiringRange(competitor *Competitor, event EventLog) error {
+	shots := p.config.TargetsPerLineOrDefault()
+
+	if visit := len(competitor.RangeVisits) - 1; visit >= 0 {
+		rangeVisit := &competitor.RangeVisits[visit]
+		unfired := max(shots-rangeVisit.Hits-rangeVisit.Misses, 0)
+		competitor.addShots(unfired)
+		rangeVisit.Leave = event.Time
+		rangeVisit.Misses += unfired
+		rangeVisit.ExpectedPenaltyLoops = rangeVisit.Misses * p.config.PenaltyLoopsPerMissOrDefault()
+	} else {
+		competitor.addShots(shots)
+	}
+	p.leaveRange(competitor.ID)
+	p.logf(slog.LevelInfo, event, "The %s left the firing range", competitor.Label())
+
+	return nil
+}
+
+// leavePenaltyLaps handles event 9 and books the penalty time to the current
+// lap and the last range visit. Without a matching event 8 the penalty laps are
+// assumed to start when the competitor left the firing range, if they have
+// not served penalty laps for that visit yet; either way the event is recorded
+// as an anomaly.
+func (p *Processor) leavePenaltyLaps(competitor *Competitor, event EventLog) error {
+	if len(competitor.PenaltyStartTimes) <= len(competitor.PenaltyEndTimes) {
+		visit := len(competitor.RangeVisits) - 1
+		if visit < 0 || competitor.RangeVisits[visit].Leave.IsZero() || competitor.RangeVisits[visit].PenaltyTime > 0 ||
+			competitor.RangeVisits[visit].Leave.After(event.Time) {
+			p.addAnomaly(competitor, event, "left the penalty laps at %s without entering them, penalty time unknown",
+				formatTime(event.Time))
+			return nil
+		}
+
+		rangeVisit := competitor.RangeVisits[visit]
+		p.addAnomaly(competitor, event, "left the penalty laps at %s without entering them, penalty time estimated from leaving firing range %d at %s",
+			formatTime(event.Time), rangeVisit.FiringRange, formatTime(rangeVisit.Leave))
+		competitor.PenaltyStartTimes = append(competitor.PenaltyStartTimes, rangeVisit.Leave)
+	}
+	lastPenaltyStart := competitor.PenaltyStartTimes[len(competitor.PenaltyStartTimes)-1]
+	penaltyTime := event.Time.Sub(lastPenaltyStart)
+	competitor.PenaltyTimes = append(competitor.PenaltyTimes, penaltyTime)
+	competitor.PenaltyVisits = append(competitor.PenaltyVisits, len(competitor.RangeVisits)-1)
+	competitor.PenaltyEndTimes = append(competitor.PenaltyEndTimes, event.Time)
+	competitor.TotalPenaltyTime += penaltyTime
+
+	lap := max(competitor.CurrentLap, 1)
+	for len(competitor.PenaltyTimePerLap) < lap {
+		competitor.PenaltyTimePerLap = append(competitor.PenaltyTimePerLap, 0)
+	}
+	competitor.PenaltyTimePerLap[lap-1] += penaltyTime
+
+	p.logf(slog.LevelDebug, event, "The penalty laps of the %s from %s to %s (%s) are booked to lap %d",
+		competitor.Label(), formatTime(lastPenaltyStart), formatTime(event.Time), formatDuration(penaltyTime), lap)
+	if visit := len(competitor.RangeVisits) - 1; visit >= 0 {
+		competitor.RangeVisits[visit].PenaltyTime += penaltyTime
+		competitor.RangeVisits[visit].PenaltyLen = p.courseAt(lastPenaltyStart).PenaltyLen
+		p.logf(slog.LevelDebug, event, "The penalty laps of the %s are matched to firing range %d, owing %d loops",
+			competitor.Label(), competitor.RangeVisits[visit].FiringRange, competitor.RangeVisits[visit].ExpectedPenaltyLoops)
+		p.checkPenaltyLoops(competitor, event, competitor.RangeVisits[visit])
+	}
+	p.logf(slog.LevelInfo, event, "The %s left the penalty laps", competitor.Label())
+
+	return nil
+}
+
+// penaltyLoopTolerance is how many penalty loops fewer than owed a competitor
+// may seem to have run before it is flagged. The estimate is rough, as it
+// assumes penalty loops are skied at the competitor's lap speed.
+const penaltyLoopTolerance = 1.0
+
+// checkPenaltyLoops flags penalty laps too short for the loops owed for visit,
+// estimating the loops run from the penalty time and the competitor's lap
+// speed, or the speed of the whole field before their first lap is done.
+func (p *Processor) checkPenaltyLoops(competitor *Competitor, event EventLog, visit RangeVisit) {
+	speed := p.lapSpeed(competitor)
+	if speed <= 0 || visit.ExpectedPenaltyLoops == 0 {
+		return
+	}
+
+	loopTime := float64(visit.PenaltyLen) / speed
+	loops := visit.PenaltyTime.Seconds() / loopTime
+	if loops < float64(visit.ExpectedPenaltyLoops)-penaltyLoopTolerance {
+		p.warnf(event, "%s ran about %.1f of the %d penalty loops owed for firing range %d",
+			competitor.Label(), loops, visit.ExpectedPenaltyLoops, visit.FiringRange)
+	}
+}
+
+// lapSpeed returns the average speed in m/s over the competitor's completed
+// laps, or over everyone's if they have none, or zero if no lap is completed.
+func (p *Processor) lapSpeed(competitor *Competitor) float64 {
+	competitors := []*Competitor{competitor}
+	if len(competitor.LapTimes) == 0 {
+		competitors = competitors[:0]
+		for _, c := range p.competitors {
+			competitors = append(competitors, c)
+		}
+	}
+
+	var distance, seconds float64
+	for _, c := range competitors {
+		for lap, lapTime := range c.LapTimes {
+			distance += float64(c.lapLength(lap, p.config))
+			seconds += lapTime.Seconds()
+		}
+	}
+	if seconds <= 0 {
+		return 0
+	}
+
+	return distance / seconds
+}
+
+// addAnomaly records an event officials should review on the competitor and
+// as a warning.
+func (p *Processor) addAnomaly(competitor *Competitor, event EventLog, format string, args ...any) {
+	anomaly := fmt.Sprintf(format, args...)
+	competitor.Anomalies = append(competitor.Anomalies, anomaly)
+	p.warnf(event, "%s %s", competitor.Label(), anomaly)
+}
+
+// endLap handles event 10; ending the last lap finishes the race. A repeated
+// event 10 within the configured debounce, or at the same time as the previous
+// one, is ignored as a duplicate. Event 10s beyond the configured laps are
+// ignored with a warning.
+func (p *Processor) endLap(competitor *Competitor, event EventLog) error {
+	if len(competitor.LapStartTimes) == 0 {
+		return errors.New("ended a main lap before starting")
+	}
+	if len(competitor.LapTimes) >= p.config.Laps {
+		p.warnf(event, "%s already completed all %d laps, event 10 ignored", competitor.Label(), p.config.Laps)
+		return errIgnored
+	}
+	if laps := len(competitor.LapTimes); laps > 0 {
+		previousEnd := competitor.LapStartTimes[laps-1].Add(competitor.LapTimes[laps-1])
+		sincePrevious := event.Time.Sub(previousEnd)
+		debounce := time.Duration(p.config.LapDebounceMillis) * time.Millisecond
+		switch {
+		case sincePrevious >= 0 && sincePrevious < debounce:
+			p.warnf(event, "%s ended lap %d again %s after the previous event 10, ignored as a duplicate",
+				competitor.Label(), laps, sincePrevious)
+			return errIgnored
+		case sincePrevious == 0:
+			p.warnf(event, "%s ended lap %d again at the same time, ignored as a duplicate event 10",
+				competitor.Label(), laps)
+			return errIgnored
+		}
+	}
+	if len(competitor.PenaltyStartTimes) > len(competitor.PenaltyEndTimes) {
+		p.logf(slog.LevelWarn, event, "The %s ended lap %d without leaving the penalty laps",
+			competitor.Label(), competitor.CurrentLap)
+	}
+
+	p.checkLapRangeVisits(competitor, event)
+
+	lastLapStart := competitor.LapStartTimes[len(competitor.LapStartTimes)-1]
+	lapTime := event.Time.Sub(lastLapStart)
+	competitor.LapTimes = append(competitor.LapTimes, lapTime)
+
+	competitor.CurrentLap++
+	if competitor.CurrentLap <= p.config.Laps {
+		competitor.LapStartTimes = append(competitor.LapStartTimes, event.Time)
+		competitor.LapLengths = append(competitor.LapLengths, p.courseAt(event.Time).LapLength(competitor.CurrentLap-1))
+	} else {
+		if len(competitor.PenaltyStartTimes) > len(competitor.PenaltyEndTimes) {
+			p.addAnomaly(competitor, event, "finished without leaving the penalty laps entered at %s, penalty time unknown",
+				formatTime(competitor.PenaltyStartTimes[len(competitor.PenaltyStartTimes)-1]))
+		}
+		competitor.FinishTime = event.Time
+		competitor.Status = "Finished"
+
+		p.emit(event.Time, EventFinished, competitor.ID)
+		p.logf(slog.LevelInfo, event, "The %s has finished", competitor.Label())
+	}
+	p.logf(slog.LevelInfo, event, "The %s ended the main lap", competitor.Label())
+
+	return nil
+}
+
+// checkLapRangeVisits flags a lap with more than one range visit, or without
+// one while some of the course's firing ranges have not been visited yet.
+func (p *Processor) checkLapRangeVisits(competitor *Competitor, event EventLog) {
+	if p.config.FiringLines <= 0 {
+		return
+	}
+
+	visits := 0
+	for _, visit := range competitor.RangeVisits {
+		if visit.Lap == competitor.CurrentLap {
+			visits++
+		}
+	}
+
+	switch {
+	case visits > 1:
+		p.warnf(event, "%s visited %d firing ranges on lap %d", competitor.Label(), visits, competitor.CurrentLap)
+	case visits == 0 && len(competitor.RangeVisits) < p.config.FiringLines:
+		p.warnf(event, "%s completed lap %d without visiting a firing range", competitor.Label(), competitor.CurrentLap)
+	}
+}
+
+// resume handles event 12 within the grace period after event 11.
+func (p *Processor) resume(competitor *Competitor, event EventLog) error {
+	if competitor.Status != "NotFinished" {
+		return errors.New("resumed without having stopped")
+	}
+	gracePeriod := time.Duration(p.config.GracePeriodSeconds) * time.Second
+	if event.Time.Sub(competitor.DNFTime) > gracePeriod {
+		return fmt.Errorf("resumed after the %s grace period", gracePeriod)
+	}
+	competitor.Status = "Started"
+	competitor.DNFReason = ""
+	competitor.Resumed = true
+	competitor.ResumeReason = event.ExtraParams
+	p.logf(slog.LevelInfo, event, "The %s resumed the race: %s", competitor.Label(), event.ExtraParams)
+
+	return nil
+}
+
+// passCheckpoint handles event 13, "<checkpoint> [<HH:MM:SS.sss>]", and
+// records the split with its gap to the fastest competitor so far. The time,
+// if given, is when the checkpoint saw the competitor, which may be earlier
+// than the event was sent; the event time is used otherwise.
+func (p *Processor) passCheckpoint(competitor *Competitor, event EventLog) error {
+	params := strings.Fields(event.ExtraParams)
+	if len(params) < 1 || len(params) > 2 {
+		return fmt.Errorf("invalid checkpoint %q, want \"<checkpoint> [<time>]\"", event.ExtraParams)
+	}
+	checkpoint, err := strconv.Atoi(params[0])
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint %q: %w", params[0], err)
+	}
+	passed := event.Time
+	if len(params) == 2 {
+		passed, err = parseTime(params[1])
+		if err != nil {
+			return fmt.Errorf("invalid checkpoint time %q: %w", params[1], err)
+		}
+		passed = nearestDay(passed, event.Time)
+	}
+	if competitor.ActualStartTime.IsZero() {
+		return errors.New("passed a checkpoint before starting")
+	}
+
+	split := SplitTime{
+		CheckpointID: checkpoint,
+		Lap:          competitor.CurrentLap,
+		At:           passed,
+		Elapsed:      competitor.elapsed(passed),
+	}
+	key := splitKey{lap: split.Lap, checkpoint: checkpoint}
+	if best, ok := p.bestSplits[key]; ok && best < split.Elapsed {
+		split.Gap = split.Elapsed - best
+	} else {
+		p.bestSplits[key] = split.Elapsed
+	}
+	competitor.Splits = append(competitor.Splits, split)
+	p.logf(slog.LevelInfo, event, "The %s passed checkpoint(%d) on lap %d, +%s behind the leader",
+		competitor.Label(), checkpoint, split.Lap, formatDuration(split.Gap))
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package biathlon
+
+// EventHandler is called after an incoming event has been applied.
+type EventHandler func(event EventLog, competitor *Competitor)
+
+// StatusChangeHandler is called after a competitor's status has changed.
+// oldStatus is empty when the competitor has just registered.
+type StatusChangeHandler func(competitor *Competitor, oldStatus, newStatus string)
+
+// OnEvent registers fn to be called whenever an event with eventID has been
+// applied. Handlers for the same event ID run in registration order.
+func (p *Processor) OnEvent(eventID int, fn func(EventLog, *Competitor)) {
+	if p.eventHandlers == nil {
+		p.eventHandlers = make(map[int][]EventHandler)
+	}
+	p.eventHandlers[eventID] = append(p.eventHandlers[eventID], fn)
+}
+
+// OnAnyEvent registers fn to be called whenever any event has been applied,
+// after the handlers for its event ID. Handlers run in registration order.
+func (p *Processor) OnAnyEvent(fn func(EventLog, *Competitor)) {
+	p.anyEventHandlers = append(p.anyEventHandlers, fn)
+}
+
+// OnStatusChange registers fn to be called whenever a competitor's status
+// changes, including registration and the start window check in Finalize.
+// Handlers run in registration order.
+func (p *Processor) OnStatusChange(fn func(competitor *Competitor, oldStatus, newStatus string)) {
+	p.statusHandlers = append(p.statusHandlers, fn)
+}
+
+func (p *Processor) notifyEvent(event EventLog, competitor *Competitor) {
+	for _, fn := range p.eventHandlers[event.EventID] {
+		fn(event, competitor)
+	}
+	for _, fn := range p.anyEventHandlers {
+		fn(event, competitor)
+	}
+}
+
+func (p *Processor) notifyStatusChange(competitor *Competitor, oldStatus string) {
+	if competitor.Status == oldStatus {
+		return
+	}
+
+	for _, fn := range p.statusHandlers {
+		fn(competitor, oldStatus, competitor.Status)
+	}
+}
@@ -0,0 +1,70 @@
+package biathlon
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestProcessorHooks(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:50:00.000] 2 1 10:00:00.000",
+		"[09:50:01.000] 2 2 10:01:30.000",
+		"[10:00:00.500] 4 1",
+		"[10:01:30.500] 4 2",
+		"[10:05:00.000] 6 1 1",
+		"[10:09:00.000] 11 2 Broken ski",
+		"[10:12:00.000] 10 1",
+	})
+
+	p := NewProcessor(config)
+
+	var calls []string
+	p.OnEvent(6, func(event EventLog, competitor *Competitor) {
+		calls = append(calls, fmt.Sprintf("first %d hits=%d", competitor.ID, competitor.Hits))
+	})
+	p.OnEvent(6, func(event EventLog, competitor *Competitor) {
+		calls = append(calls, fmt.Sprintf("second %d hits=%d", competitor.ID, competitor.Hits))
+	})
+	var anyEvents []int
+	p.OnAnyEvent(func(event EventLog, competitor *Competitor) {
+		if event.EventID == 6 {
+			calls = append(calls, fmt.Sprintf("any %d hits=%d", competitor.ID, competitor.Hits))
+		}
+		anyEvents = append(anyEvents, event.EventID)
+	})
+
+	transitions := make(map[int][]string)
+	p.OnStatusChange(func(competitor *Competitor, oldStatus, newStatus string) {
+		if competitor.Status != newStatus {
+			t.Errorf("Handler should see the new status %s, competitor has %s", newStatus, competitor.Status)
+		}
+		transitions[competitor.ID] = append(transitions[competitor.ID], oldStatus+"->"+newStatus)
+	})
+
+	for _, event := range events {
+		if err := p.AddEvent(event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	expectedCalls := []string{"first 1 hits=1", "second 1 hits=1", "any 1 hits=1"}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf("Expected event handler calls %v, got %v", expectedCalls, calls)
+	}
+	if expectedEvents := []int{1, 1, 2, 2, 4, 4, 6, 11, 10}; !reflect.DeepEqual(anyEvents, expectedEvents) {
+		t.Errorf("Expected every event to be handled, got %v", anyEvents)
+	}
+
+	expected := map[int][]string{
+		1: {"->NotStarted", "NotStarted->Started", "Started->Finished"},
+		2: {"->NotStarted", "NotStarted->Started", "Started->NotFinished"},
+	}
+	if !reflect.DeepEqual(transitions, expected) {
+		t.Errorf("Expected status transitions %v, got %v", expected, transitions)
+	}
+}
@@ -0,0 +1,69 @@
+package biathlon
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"os"
+)
+
+var htmlFuncs = template.FuncMap{
+	"formatDuration": formatDuration,
+	"formatMinutes":  formatMinutes,
+}
+
+//go:embed report.html.tmpl
+var defaultHTMLTemplateText string
+
+var defaultHTMLTemplate = template.Must(template.New("report").Funcs(htmlFuncs).Parse(defaultHTMLTemplateText))
+
+// HTMLReport is the data HTML report templates are executed with.
+type HTMLReport struct {
+	Config Configuration
+	Laps   []int // lap numbers from 1, for the table header
+	Rows   []HTMLRow
+}
+
+// HTMLRow is a result row whose Laps has an entry for every configured lap,
+// empty for laps not completed.
+type HTMLRow struct {
+	ResultRow
+}
+
+// ParseHTMLTemplate reads a template to use instead of the built-in one. It is
+// executed with an HTMLReport and may call formatDuration and formatMinutes.
+func ParseHTMLTemplate(path string) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return template.New(path).Funcs(htmlFuncs).Parse(string(text))
+}
+
+// WriteHTMLReport renders the final results to w as a self-contained HTML
+// page using tmpl, or the built-in template if tmpl is nil.
+func WriteHTMLReport(w io.Writer, competitors map[int]*Competitor, config Configuration, tmpl *template.Template) error {
+	return writeHTMLReport(w, BuildResults(competitors, config), config, tmpl)
+}
+
+func writeHTMLReport(w io.Writer, rows []ResultRow, config Configuration, tmpl *template.Template) error {
+	if tmpl == nil {
+		tmpl = defaultHTMLTemplate
+	}
+
+	report := HTMLReport{Config: config}
+	for i := 1; i <= config.Laps; i++ {
+		report.Laps = append(report.Laps, i)
+	}
+
+	for _, row := range rows {
+		htmlRow := HTMLRow{ResultRow: row}
+		htmlRow.Laps = make([]LapStats, max(config.Laps, len(row.Laps)))
+		copy(htmlRow.Laps, row.Laps)
+
+		report.Rows = append(report.Rows, htmlRow)
+	}
+
+	return tmpl.Execute(w, report)
+}
@@ -0,0 +1,104 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteReportHTMLSampleRace(t *testing.T) {
+	config, _, err := LoadConfiguration("../sunny_5_skiers/config.json", "")
+	if err != nil {
+		t.Fatalf("Unexpected error loading sample configuration: %v", err)
+	}
+	eventsFile, err := os.Open("../sunny_5_skiers/events")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sample events: %v", err)
+	}
+	defer eventsFile.Close()
+
+	events, err := ReadEvents(context.Background(), eventsFile)
+	if err != nil {
+		t.Fatalf("Unexpected error reading sample events: %v", err)
+	}
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config)
+	if err != nil {
+		t.Fatalf("Unexpected error processing sample events: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatHTML); err != nil {
+		t.Fatalf("Unexpected error writing HTML report: %v", err)
+	}
+
+	for _, expected := range []string{
+		"<p>\n2 laps of 3500 m, 2 firing lines, penalty loops of 150 m.",
+		"<th>Lap 1</th><th>Lap 2</th>",
+		"<tr class=\"status-Finished\">\n<td>1</td>\n<td>2</td>\n<td>Finished</td>\n<td>00:25:18.356</td>\n<td>+00:00.000</td>\n" +
+			"<td>00:12:38.243</td>\n<td>00:12:38.610</td>\n<td>00:01:40.000</td>\n<td>8/10</td>\n</tr>",
+		"<td>5</td>\n<td>5</td>\n<td>Finished</td>\n<td>00:26:22.472</td>",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, buf.String())
+		}
+	}
+}
+
+func TestWriteReportHTMLEscaping(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3000, PenaltyLen: 150}
+	competitors := map[int]*Competitor{
+		1: {
+			ID:        1,
+			Status:    "NotFinished",
+			DNFReason: `<script>alert("fell")</script>`,
+			LapTimes:  []time.Duration{10 * time.Minute},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatHTML); err != nil {
+		t.Fatalf("Unexpected error writing HTML report: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "<script>") {
+		t.Errorf("Expected the DNF reason to be escaped, got:\n%s", buf.String())
+	}
+	expected := "<td>NotFinished (&lt;script&gt;alert(&#34;fell&#34;)&lt;/script&gt;)</td>"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected %q in the report, got:\n%s", expected, buf.String())
+	}
+	if !strings.Contains(buf.String(), "<td>00:10:00.000</td>\n<td></td>") {
+		t.Errorf("Expected an empty cell for the lap not completed, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteHTMLReportTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.tmpl")
+	text := `{{range .Rows}}{{.Place}}. {{.Name}} {{formatDuration .TotalTime}}{{"\n"}}{{end}}`
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := ParseHTMLTemplate(path)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing template: %v", err)
+	}
+
+	config := Configuration{Laps: 1, LapLen: 3000, PenaltyLen: 150}
+	start := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+	competitors := map[int]*Competitor{
+		1: {ID: 1, Name: "Anna & Ole", Status: "Finished", ActualStartTime: start, FinishTime: start.Add(20 * time.Minute)},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHTMLReport(&buf, competitors, config, tmpl); err != nil {
+		t.Fatalf("Unexpected error writing HTML report: %v", err)
+	}
+	if buf.String() != "1. Anna &amp; Ole 00:20:00.000\n" {
+		t.Errorf("Unexpected report %q", buf.String())
+	}
+}
@@ -0,0 +1,79 @@
+package biathlon
+
+import (
+	"sort"
+	"time"
+)
+
+// LeaderboardEntry is one competitor's line of the live standings.
+type LeaderboardEntry struct {
+	Position     int      `json:"position"`
+	CompetitorID int      `json:"competitorID"`
+	Name         string   `json:"name,omitempty"`
+	Status       string   `json:"status"`
+	Laps         int      `json:"laps"`
+	Elapsed      string   `json:"elapsed"`
+	LapTimes     []string `json:"lapTimes"` // cumulative time at the end of every lap
+}
+
+// LeaderboardUpdate is written after every completed lap (event 10).
+type LeaderboardUpdate struct {
+	Time         string             `json:"time"`
+	CompetitorID int                `json:"competitorID"`
+	Standings    []LeaderboardEntry `json:"standings"`
+}
+
+// BuildLeaderboard ranks the competitors who completed at least one lap: more
+// laps first, then by elapsed time since their actual start.
+func BuildLeaderboard(competitors map[int]*Competitor) []LeaderboardEntry {
+	type standing struct {
+		competitor *Competitor
+		elapsed    time.Duration
+	}
+
+	var standings []standing
+	for _, competitor := range competitors {
+		if len(competitor.LapTimes) == 0 {
+			continue
+		}
+
+		var elapsed time.Duration
+		for _, lapTime := range competitor.LapTimes {
+			elapsed += lapTime
+		}
+		standings = append(standings, standing{competitor: competitor, elapsed: elapsed})
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		si, sj := standings[i], standings[j]
+		if len(si.competitor.LapTimes) != len(sj.competitor.LapTimes) {
+			return len(si.competitor.LapTimes) > len(sj.competitor.LapTimes)
+		}
+		if si.elapsed != sj.elapsed {
+			return si.elapsed < sj.elapsed
+		}
+		return si.competitor.ID < sj.competitor.ID
+	})
+
+	entries := make([]LeaderboardEntry, 0, len(standings))
+	for i, s := range standings {
+		entry := LeaderboardEntry{
+			Position:     i + 1,
+			CompetitorID: s.competitor.ID,
+			Name:         s.competitor.Name,
+			Status:       s.competitor.Status,
+			Laps:         len(s.competitor.LapTimes),
+			Elapsed:      formatDuration(s.elapsed),
+		}
+
+		var cumulative time.Duration
+		for _, lapTime := range s.competitor.LapTimes {
+			cumulative += lapTime
+			entry.LapTimes = append(entry.LapTimes, formatDuration(cumulative))
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
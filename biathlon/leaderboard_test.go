@@ -0,0 +1,107 @@
+package biathlon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestWithLeaderboard(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:30:02.000] 1 3",
+		"[10:00:00.000] 4 1",
+		"[10:01:00.000] 4 2",
+		"[10:02:00.000] 4 3",
+		"[10:12:00.000] 10 1",
+		"[10:12:30.000] 10 2",
+		"[10:24:30.000] 10 2",
+	})
+
+	var buf bytes.Buffer
+	if _, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict), WithLeaderboard(&buf)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var updates []LeaderboardUpdate
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var update LeaderboardUpdate
+		if err := json.Unmarshal(scanner.Bytes(), &update); err != nil {
+			t.Fatalf("Unexpected error decoding update %q: %v", scanner.Text(), err)
+		}
+		updates = append(updates, update)
+	}
+
+	if len(updates) != 3 {
+		t.Fatalf("Expected one update per completed lap, got %d", len(updates))
+	}
+
+	expected := []struct {
+		time         string
+		competitorID int
+		order        []int
+	}{
+		{"10:12:00.000", 1, []int{1}},
+		{"10:12:30.000", 2, []int{2, 1}},
+		{"10:24:30.000", 2, []int{2, 1}},
+	}
+
+	for i, e := range expected {
+		update := updates[i]
+		if update.Time != e.time || update.CompetitorID != e.competitorID {
+			t.Errorf("Update %d: expected %s for competitor %d, got %s for competitor %d",
+				i, e.time, e.competitorID, update.Time, update.CompetitorID)
+		}
+
+		if len(update.Standings) != len(e.order) {
+			t.Errorf("Update %d: expected %d standings, got %+v", i, len(e.order), update.Standings)
+			continue
+		}
+
+		for j, id := range e.order {
+			if update.Standings[j].CompetitorID != id || update.Standings[j].Position != j+1 {
+				t.Errorf("Update %d: expected competitor %d at position %d, got %+v", i, id, j+1, update.Standings[j])
+			}
+		}
+	}
+
+	leader := updates[2].Standings[0]
+	if leader.Status != "Finished" || leader.Elapsed != "00:23:30.000" || len(leader.LapTimes) != 2 || leader.LapTimes[0] != "00:11:30.000" {
+		t.Errorf("Unexpected leader entry: %+v", leader)
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWithLeaderboardWriteError(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:12:00.000] 10 1",
+		"[10:24:00.000] 10 1",
+	})
+
+	_, _, warnings, err := ProcessEvents(context.Background(), events, config, WithMode(Strict), WithLeaderboard(failingWriter{}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "[10:12:00.000] event 10 for competitor(1): writing the leaderboard failed, no more updates are written: disk full"
+	if len(warnings) != 1 || warnings[0].String() != expected {
+		t.Errorf("Expected one warning %q, got %v", expected, warnings)
+	}
+}
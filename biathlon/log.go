@@ -0,0 +1,86 @@
+package biathlon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// NarrationHandler is a slog.Handler that writes records as
+// "[HH:MM:SS.sss] message" lines, the format of the competition log.
+// The record time is the event time; attributes are not written.
+type NarrationHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+}
+
+// NewNarrationHandler returns a NarrationHandler writing to w. Only
+// opts.Level is used; a nil opts logs at slog.LevelInfo and above.
+func NewNarrationHandler(w io.Writer, opts *slog.HandlerOptions) *NarrationHandler {
+	var level slog.Leveler = slog.LevelInfo
+	if opts != nil && opts.Level != nil {
+		level = opts.Level
+	}
+
+	return &NarrationHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *NarrationHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *NarrationHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := fmt.Fprintf(h.w, "[%s] %s\n", formatTime(r.Time), r.Message)
+	return err
+}
+
+func (h *NarrationHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *NarrationHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// NewCompetitorFilter returns a handler passing on to h only the records about
+// the competitors with the given IDs, as told by their competitorID attribute.
+// Records without one are passed on.
+func NewCompetitorFilter(h slog.Handler, ids []int) slog.Handler {
+	return &competitorFilter{Handler: h, ids: ids}
+}
+
+type competitorFilter struct {
+	slog.Handler
+	ids []int
+}
+
+func (f *competitorFilter) Handle(ctx context.Context, r slog.Record) error {
+	pass := true
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key != "competitorID" || a.Value.Kind() != slog.KindInt64 {
+			return true
+		}
+		pass = slices.Contains(f.ids, int(a.Value.Int64()))
+		return false
+	})
+	if !pass {
+		return nil
+	}
+
+	return f.Handler.Handle(ctx, r)
+}
+
+func (f *competitorFilter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &competitorFilter{Handler: f.Handler.WithAttrs(attrs), ids: f.ids}
+}
+
+func (f *competitorFilter) WithGroup(name string) slog.Handler {
+	return &competitorFilter{Handler: f.Handler.WithGroup(name), ids: f.ids}
+}
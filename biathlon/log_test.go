@@ -0,0 +1,71 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNarrationHandler(t *testing.T) {
+	at := time.Date(0, 1, 1, 10, 0, 1, 744_000_000, time.UTC)
+	tests := []struct {
+		name     string
+		opts     *slog.HandlerOptions
+		level    slog.Level
+		expected string
+	}{
+		{"info by default", nil, slog.LevelInfo, "[10:00:01.744] The competitor(1) has started\n"},
+		{"debug hidden by default", nil, slog.LevelDebug, ""},
+		{"debug shown", &slog.HandlerOptions{Level: slog.LevelDebug}, slog.LevelDebug, "[10:00:01.744] The competitor(1) has started\n"},
+		{"info hidden", &slog.HandlerOptions{Level: slog.LevelWarn}, slog.LevelInfo, ""},
+		{"warn shown", &slog.HandlerOptions{Level: slog.LevelWarn}, slog.LevelWarn, "[10:00:01.744] The competitor(1) has started\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := slog.New(NewNarrationHandler(&out, tt.opts)).With("competitorID", 1)
+
+			r := slog.NewRecord(at, tt.level, "The competitor(1) has started", 0)
+			if logger.Enabled(context.Background(), tt.level) {
+				if err := logger.Handler().Handle(context.Background(), r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if out.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestCompetitorFilter(t *testing.T) {
+	at := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	logger := slog.New(NewCompetitorFilter(NewNarrationHandler(&out, nil), []int{7, 12}))
+	logger.LogAttrs(context.Background(), slog.LevelInfo, "about 7", slog.Int("competitorID", 7))
+	logger.LogAttrs(context.Background(), slog.LevelInfo, "about 8", slog.Int("competitorID", 8))
+	logger.With("eventID", 4).LogAttrs(context.Background(), slog.LevelInfo, "about 12", slog.Int("competitorID", 12))
+	logger.Info("about the race")
+
+	r := slog.NewRecord(at, slog.LevelInfo, "about 3", 0)
+	r.AddAttrs(slog.Int("competitorID", 3))
+	if err := logger.Handler().Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expected := []string{"about 7", "about 12", "about the race"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %q", len(expected), lines)
+	}
+	for i, message := range expected {
+		if !strings.HasSuffix(lines[i], message) {
+			t.Errorf("Expected line %d to be %q, got %q", i+1, message, lines[i])
+		}
+	}
+}
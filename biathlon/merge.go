@@ -0,0 +1,121 @@
+package biathlon
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// EventSource is an event log to merge with others, e.g. the file of one
+// timing station. Name identifies it in parse errors.
+type EventSource struct {
+	Name   string
+	Reader io.Reader
+}
+
+// MergeEvents reads several event logs, each in chronological order, and
+// merges them into one. It is a k-way merge: every source is read one event at
+// a time, so the events are never sorted as a whole. Events at the same time
+// keep the order of the sources, then of the lines within a source.
+//
+// Malformed lines are skipped and returned joined as *LineError values naming
+// their source, like ReadEvents does.
+func MergeEvents(ctx context.Context, sources []EventSource) ([]EventLog, error) {
+	var lineErrs []error
+	var streams eventStreams
+	for i, source := range sources {
+		stream := &eventStream{index: i, name: source.Name, scanner: bufio.NewScanner(source.Reader)}
+		ok, err := stream.next(&lineErrs)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			streams = append(streams, stream)
+		}
+	}
+	heap.Init(&streams)
+
+	var events []EventLog
+	for len(streams) > 0 {
+		if len(events)%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return events, err
+			}
+		}
+
+		stream := streams[0]
+		events = append(events, stream.head)
+
+		ok, err := stream.next(&lineErrs)
+		if err != nil {
+			return events, err
+		}
+		if ok {
+			heap.Fix(&streams, 0)
+		} else {
+			heap.Pop(&streams)
+		}
+	}
+
+	return events, errors.Join(lineErrs...)
+}
+
+// eventStream is a source being merged, with its next event in head.
+type eventStream struct {
+	index   int
+	name    string
+	scanner *bufio.Scanner
+	line    int
+	head    EventLog
+}
+
+// next reads the next event of the stream into head and reports whether there
+// was one. Malformed lines are added to lineErrs.
+func (s *eventStream) next(lineErrs *[]error) (bool, error) {
+	for s.scanner.Scan() {
+		s.line++
+		line := s.scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		event, err := ParseEventLog(line)
+		if err != nil {
+			*lineErrs = append(*lineErrs, &LineError{Source: s.name, Line: s.line, Err: err})
+			continue
+		}
+		s.head = event
+
+		return true, nil
+	}
+
+	return false, s.scanner.Err()
+}
+
+// eventStreams is a heap of streams ordered by their next event.
+type eventStreams []*eventStream
+
+func (h eventStreams) Len() int { return len(h) }
+
+func (h eventStreams) Less(i, j int) bool {
+	if !h[i].head.Time.Equal(h[j].head.Time) {
+		return h[i].head.Time.Before(h[j].head.Time)
+	}
+
+	return h[i].index < h[j].index
+}
+
+func (h eventStreams) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *eventStreams) Push(x any) { *h = append(*h, x.(*eventStream)) }
+
+func (h *eventStreams) Pop() any {
+	old := *h
+	stream := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return stream
+}
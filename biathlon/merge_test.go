@@ -0,0 +1,76 @@
+package biathlon
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeEvents(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3000, PenaltyLen: 150, FiringLines: 1}
+
+	// Competitor 1 completes the first lap as they arrive at the range. The
+	// finish station is listed first, so the lap comes before the range
+	start := strings.Join([]string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[10:00:00.000] 4 1",
+		"[10:00:30.000] 4 2",
+	}, "\n")
+	firingRange := strings.Join([]string{
+		"[10:10:00.000] 5 1 1",
+		"[10:10:05.000] 6 1 1",
+		"[10:10:30.000] 7 1",
+		"[10:10:40.000] 8 1",
+		"[10:11:40.000] 9 1",
+		"[10:12:00.000] 5 2 1",
+		"not an event",
+		"[10:12:30.000] 7 2",
+	}, "\n")
+	finish := strings.Join([]string{
+		"[10:08:00.000] 10 2",
+		"[10:10:00.000] 10 1",
+		"[10:20:00.000] 10 1",
+		"[10:21:00.000] 10 2",
+	}, "\n")
+
+	events, err := MergeEvents(context.Background(), []EventSource{
+		{Name: "finish", Reader: strings.NewReader(finish)},
+		{Name: "start", Reader: strings.NewReader(start)},
+		{Name: "range", Reader: strings.NewReader(firingRange)},
+	})
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Error() != "range: line 7: invalid event log format: not an event" {
+		t.Fatalf("Expected the malformed line of the range file, got %v", err)
+	}
+	if len(events) != 15 {
+		t.Fatalf("Expected 15 events, got %d", len(events))
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Time.Before(events[i-1].Time) {
+			t.Errorf("Event %d at %s is earlier than the one before it", i, formatTime(events[i].Time))
+		}
+	}
+	if events[5].EventID != 10 || events[6].EventID != 5 {
+		t.Errorf("Expected the finish station's event first at 10:10:00.000, got %+v then %+v", events[5], events[6])
+	}
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict), WithStateValidation(true))
+	if err != nil {
+		t.Fatalf("Unexpected error processing merged events: %v", err)
+	}
+
+	expected := map[int][]time.Duration{
+		1: {10 * time.Minute, 10 * time.Minute},
+		2: {7*time.Minute + 30*time.Second, 13 * time.Minute},
+	}
+	for id, laps := range expected {
+		competitor := competitors[id]
+		if competitor.Status != "Finished" || len(competitor.LapTimes) != 2 ||
+			competitor.LapTimes[0] != laps[0] || competitor.LapTimes[1] != laps[1] {
+			t.Errorf("Expected competitor %d to finish with laps %v, got %s with %v", id, laps, competitor.Status, competitor.LapTimes)
+		}
+	}
+}
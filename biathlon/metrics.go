@@ -0,0 +1,122 @@
+package biathlon
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// metricStatuses are the competitor statuses race_competitors_total reports,
+// in order. Every status is reported, even without competitors.
+var metricStatuses = []string{"NotStarted", "Started", "Finished", "NotFinished", "Withdrew", "Disqualified"}
+
+// Metrics exposes the state of a race in the Prometheus text format. It is
+// updated by a Processor created WithMetrics and may be read concurrently,
+// e.g. by serving it over HTTP.
+type Metrics struct {
+	mu       sync.Mutex
+	snapshot metricsSnapshot
+}
+
+// metricsSnapshot is the race state at one point, replaced as a whole after
+// every event so a scrape never sees an event half applied.
+type metricsSnapshot struct {
+	competitors   map[string]int // by status
+	shots         int
+	hits          int
+	lapsCompleted map[int]int // by competitor ID
+	onCourse      int
+}
+
+// NewMetrics returns Metrics for a race that has not begun.
+func NewMetrics() *Metrics {
+	return &Metrics{snapshot: takeMetricsSnapshot(nil)}
+}
+
+// WithMetrics keeps m up to date with the race after every event, and after
+// the status changes in Finalize.
+func WithMetrics(m *Metrics) Option {
+	return func(p *Processor) {
+		p.OnAnyEvent(func(EventLog, *Competitor) {
+			m.update(p.competitors)
+		})
+		p.OnStatusChange(func(*Competitor, string, string) {
+			m.update(p.competitors)
+		})
+	}
+}
+
+func takeMetricsSnapshot(competitors map[int]*Competitor) metricsSnapshot {
+	snapshot := metricsSnapshot{
+		competitors:   make(map[string]int),
+		lapsCompleted: make(map[int]int),
+	}
+	for _, competitor := range competitors {
+		snapshot.competitors[competitor.Status]++
+		snapshot.shots += competitor.Shots
+		snapshot.hits += competitor.Hits
+		snapshot.lapsCompleted[competitor.ID] = len(competitor.LapTimes)
+		if competitor.IsOnCourse() {
+			snapshot.onCourse++
+		}
+	}
+
+	return snapshot
+}
+
+func (m *Metrics) update(competitors map[int]*Competitor) {
+	snapshot := takeMetricsSnapshot(competitors)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshot = snapshot
+}
+
+// WriteText writes the metrics to w in the Prometheus text exposition format.
+func (m *Metrics) WriteText(w io.Writer) error {
+	m.mu.Lock()
+	snapshot := m.snapshot
+	m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP race_competitors_total Competitors by status.\n")
+	b.WriteString("# TYPE race_competitors_total gauge\n")
+	for _, status := range metricStatuses {
+		fmt.Fprintf(&b, "race_competitors_total{status=%q} %d\n", status, snapshot.competitors[status])
+	}
+
+	b.WriteString("# HELP race_shots_total Shots fired by all competitors.\n")
+	b.WriteString("# TYPE race_shots_total counter\n")
+	fmt.Fprintf(&b, "race_shots_total %d\n", snapshot.shots)
+
+	b.WriteString("# HELP race_hits_total Targets hit by all competitors.\n")
+	b.WriteString("# TYPE race_hits_total counter\n")
+	fmt.Fprintf(&b, "race_hits_total %d\n", snapshot.hits)
+
+	ids := make([]int, 0, len(snapshot.lapsCompleted))
+	for id := range snapshot.lapsCompleted {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	b.WriteString("# HELP race_laps_completed_total Main laps completed by each competitor.\n")
+	b.WriteString("# TYPE race_laps_completed_total counter\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "race_laps_completed_total{competitor=\"%d\"} %d\n", id, snapshot.lapsCompleted[id])
+	}
+
+	b.WriteString("# HELP race_active_on_course Competitors racing right now.\n")
+	b.WriteString("# TYPE race_active_on_course gauge\n")
+	fmt.Fprintf(&b, "race_active_on_course %d\n", snapshot.onCourse)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP serves the metrics for a Prometheus scrape.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WriteText(w)
+}
@@ -0,0 +1,99 @@
+package biathlon
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150, FiringLines: 1, Start: "10:00:00.000", StartDelta: "00:01:30"}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:31:00.000] 1 2",
+		"[09:32:00.000] 1 3",
+		"[10:00:10.000] 4 1",
+		"[10:01:40.000] 4 2",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:10.000] 6 1 1",
+		"[10:05:12.000] 6 1 2",
+		"[10:05:30.000] 7 1",
+		"[10:12:00.000] 10 1",
+		"[10:13:00.000] 11 2 Broken ski",
+	})
+
+	metrics := NewMetrics()
+	p := NewProcessor(config, WithMetrics(metrics))
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var out strings.Builder
+	if err := metrics.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP race_competitors_total Competitors by status.
+# TYPE race_competitors_total gauge
+race_competitors_total{status="NotStarted"} 1
+race_competitors_total{status="Started"} 1
+race_competitors_total{status="Finished"} 0
+race_competitors_total{status="NotFinished"} 1
+race_competitors_total{status="Withdrew"} 0
+race_competitors_total{status="Disqualified"} 0
+# HELP race_shots_total Shots fired by all competitors.
+# TYPE race_shots_total counter
+race_shots_total 5
+# HELP race_hits_total Targets hit by all competitors.
+# TYPE race_hits_total counter
+race_hits_total 2
+# HELP race_laps_completed_total Main laps completed by each competitor.
+# TYPE race_laps_completed_total counter
+race_laps_completed_total{competitor="1"} 1
+race_laps_completed_total{competitor="2"} 0
+race_laps_completed_total{competitor="3"} 0
+# HELP race_active_on_course Competitors racing right now.
+# TYPE race_active_on_course gauge
+race_active_on_course 1
+`
+	if out.String() != expected {
+		t.Errorf("Expected metrics:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	// Competitor 3 misses the start window when the race is finalized
+	p.Finalize()
+	out.Reset()
+	if err := metrics.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `race_competitors_total{status="Disqualified"} 1`) {
+		t.Errorf("Expected the disqualification in the metrics, got:\n%s", out.String())
+	}
+}
+
+func TestMetricsConcurrentScrapes(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+	metrics := NewMetrics()
+	p := NewProcessor(config, WithMetrics(metrics))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			var out strings.Builder
+			if err := metrics.WriteText(&out); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for _, event := range parseEvents(t, []string{"[09:30:00.000] 1 1", "[10:00:00.000] 4 1", "[10:12:00.000] 10 1"}) {
+		if err := p.AddEvent(event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,48 @@
+package biathlon
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseNames reads a tab-separated "competitorID<TAB>full name" list, one
+// competitor per line. Blank lines are ignored.
+func ParseNames(r io.Reader) (map[int]string, error) {
+	names := make(map[int]string)
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		idStr, name, found := strings.Cut(line, "\t")
+		if !found {
+			return nil, &LineError{Line: lineNum, Err: fmt.Errorf("missing tab separator: %s", line)}
+		}
+
+		id, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			return nil, &LineError{Line: lineNum, Err: fmt.Errorf("invalid competitor ID: %s", idStr)}
+		}
+
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, &LineError{Line: lineNum, Err: fmt.Errorf("empty name for competitor(%d)", id)}
+		}
+
+		names[id] = name
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
@@ -0,0 +1,43 @@
+package biathlon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNames(t *testing.T) {
+	input := "1\tAnna Svensson\n" +
+		"\n" +
+		"2\tOle Einar\n"
+
+	names, err := ParseNames(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(names) != 2 || names[1] != "Anna Svensson" || names[2] != "Ole Einar" {
+		t.Errorf("Unexpected names: %v", names)
+	}
+
+	invalid := []string{
+		"1 Anna Svensson\n",
+		"one\tAnna Svensson\n",
+		"1\t\n",
+	}
+
+	for _, input := range invalid {
+		if _, err := ParseNames(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected error for input %q, but got none", input)
+		}
+	}
+}
+
+func TestCompetitorLabel(t *testing.T) {
+	if label := (&Competitor{ID: 1}).Label(); label != "competitor(1)" {
+		t.Errorf("Expected competitor(1), got %s", label)
+	}
+
+	if label := (&Competitor{ID: 1, Name: "Anna Svensson"}).Label(); label != "competitor Anna Svensson(1)" {
+		t.Errorf("Expected competitor Anna Svensson(1), got %s", label)
+	}
+}
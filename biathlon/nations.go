@@ -0,0 +1,102 @@
+package biathlon
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// NationResult is the combined result of the competitors of one nation.
+type NationResult struct {
+	Nation    string
+	Starters  int
+	Finishers int
+	Shots     int
+	Hits      int
+
+	// ScoreTime is the sum of the total times of the best Scored finishers,
+	// at most the configured nationScoreCount.
+	Scored    int
+	ScoreTime time.Duration
+}
+
+// computeNationResults groups the competitors with a nation by it. Nations
+// with more scoring finishers rank first, then the lower score time.
+func computeNationResults(competitors []*Competitor, n int) []NationResult {
+	byNation := make(map[string]*NationResult)
+	finishTimes := make(map[string][]time.Duration)
+	for _, competitor := range competitors {
+		if competitor.Nation == "" {
+			continue
+		}
+
+		result, ok := byNation[competitor.Nation]
+		if !ok {
+			result = &NationResult{Nation: competitor.Nation}
+			byNation[competitor.Nation] = result
+		}
+		if !competitor.ActualStartTime.IsZero() {
+			result.Starters++
+		}
+		if competitor.Status == "Finished" {
+			result.Finishers++
+			finishTimes[competitor.Nation] = append(finishTimes[competitor.Nation], competitor.TotalRaceTime())
+		}
+		result.Shots += competitor.Shots
+		result.Hits += competitor.Hits
+	}
+
+	results := make([]NationResult, 0, len(byNation))
+	for nation, result := range byNation {
+		times := finishTimes[nation]
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+		result.Scored = min(len(times), n)
+		for _, t := range times[:result.Scored] {
+			result.ScoreTime += t
+		}
+		results = append(results, *result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		ri, rj := results[i], results[j]
+		if ri.Scored != rj.Scored {
+			return ri.Scored > rj.Scored
+		}
+		if ri.ScoreTime != rj.ScoreTime {
+			return ri.ScoreTime < rj.ScoreTime
+		}
+		return ri.Nation < rj.Nation
+	})
+
+	return results
+}
+
+// writeNationResults writes the Nations section of the text report, unless no
+// competitor has a nation.
+func writeNationResults(w io.Writer, competitors map[int]*Competitor, config Configuration) error {
+	list := make([]*Competitor, 0, len(competitors))
+	for _, competitor := range competitors {
+		list = append(list, competitor)
+	}
+	results := computeNationResults(list, config.NationScoreCountOrDefault())
+	if len(results) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(w, "\nNations:"); err != nil {
+		return err
+	}
+	for _, result := range results {
+		score := "NT"
+		if result.Scored > 0 {
+			score = formatDuration(result.ScoreTime)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %d starters, %d finishers, %d/%d hits, best %d %s\n",
+			result.Nation, result.Starters, result.Finishers, result.Hits, result.Shots, result.Scored, score); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
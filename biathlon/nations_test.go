@@ -0,0 +1,84 @@
+package biathlon
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComputeNationResults(t *testing.T) {
+	start, _ := parseTime("[10:00:00.000]")
+	finisher := func(id int, nation string, totalTime time.Duration, hits int) *Competitor {
+		return &Competitor{
+			ID:              id,
+			Nation:          nation,
+			Status:          "Finished",
+			ActualStartTime: start,
+			FinishTime:      start.Add(totalTime),
+			Hits:            hits,
+			Shots:           10,
+		}
+	}
+	competitors := []*Competitor{
+		finisher(1, "NOR", 25*time.Minute, 9),
+		finisher(2, "NOR", 27*time.Minute, 8),
+		finisher(3, "NOR", 26*time.Minute, 10),
+		finisher(4, "SWE", 24*time.Minute, 7),
+		finisher(5, "SWE", 29*time.Minute, 9),
+		{ID: 6, Nation: "SWE", Status: "NotFinished", ActualStartTime: start, Hits: 3, Shots: 5},
+		finisher(7, "FIN", 20*time.Minute, 10),
+		{ID: 8, Nation: "GER", Status: "NotStarted"},
+		finisher(9, "", 21*time.Minute, 10),
+	}
+
+	expected := []NationResult{
+		{Nation: "NOR", Starters: 3, Finishers: 3, Shots: 30, Hits: 27, Scored: 2, ScoreTime: 51 * time.Minute},
+		{Nation: "SWE", Starters: 3, Finishers: 2, Shots: 25, Hits: 19, Scored: 2, ScoreTime: 53 * time.Minute},
+		{Nation: "FIN", Starters: 1, Finishers: 1, Shots: 10, Hits: 10, Scored: 1, ScoreTime: 20 * time.Minute},
+		{Nation: "GER"},
+	}
+
+	results := computeNationResults(competitors, 2)
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, results)
+	}
+}
+
+func TestWriteReportNations(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, NationScoreCount: 1}
+	start, _ := parseTime("[10:00:00.000]")
+	competitors := map[int]*Competitor{
+		1: {ID: 1, Nation: "NOR", Status: "Finished", ActualStartTime: start, FinishTime: start.Add(12 * time.Minute), Hits: 4, Shots: 5},
+		2: {ID: 2, Nation: "NOR", Status: "NotStarted"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "\nNations:\nNOR: 1 starters, 1 finishers, 4/5 hits, best 1 00:12:00.000\n") {
+		t.Errorf("Expected the nations section at the end of the report, got:\n%s", buf.String())
+	}
+
+	// Filtering the individual results keeps the nation's whole team
+	buf.Reset()
+	filtered := config
+	filtered.Competitors = []int{2}
+	if err := WriteReport(&buf, competitors, filtered, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "\nNations:\nNOR: 1 starters, 1 finishers, 4/5 hits, best 1 00:12:00.000\n") {
+		t.Errorf("Expected the nations section to cover the whole field, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	competitors[1].Nation, competitors[2].Nation = "", ""
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	if strings.Contains(buf.String(), "Nations") {
+		t.Errorf("Expected no nations section without nations, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,149 @@
+package biathlon
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Option configures a Processor.
+type Option func(*Processor)
+
+// WithLogger sets the logger receiving the commentary. By default it is discarded.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Processor) {
+		p.logger = logger
+	}
+}
+
+// WithOutgoing sets the writer receiving outgoing events (32, 33). By default
+// they are only collected.
+func WithOutgoing(w io.Writer) Option {
+	return func(p *Processor) {
+		p.outgoing = w
+	}
+}
+
+// WithOutput writes the commentary and the outgoing events to w, in the order
+// they happen. Processors with outputs of their own share no state, so they
+// may run concurrently.
+func WithOutput(w io.Writer) Option {
+	return func(p *Processor) {
+		p.logger = slog.New(NewNarrationHandler(w, nil))
+		p.outgoing = w
+	}
+}
+
+// WithNames sets the competitor names used for competitors registered afterwards.
+func WithNames(names map[int]string) Option {
+	return func(p *Processor) {
+		p.names = names
+	}
+}
+
+// WithStartList registers the competitors on the start list before the race.
+// Their event 1 then confirms the registration, while registering anyone not
+// on the list is warned about.
+func WithStartList(entries []StartListEntry) Option {
+	return func(p *Processor) {
+		p.startList = entries
+	}
+}
+
+// WithPlannedStartTimes fixes the planned start times of the given
+// competitors, e.g. for a pursuit where they start as far behind as they
+// finished the previous race. These take precedence over drawn start times
+// (event 2).
+func WithPlannedStartTimes(starts map[int]time.Time) Option {
+	return func(p *Processor) {
+		p.plannedStarts = starts
+	}
+}
+
+// WithRaceDate anchors event times to date: the first event is on that date
+// and later ones on the same or a following day. By default event times carry
+// no date.
+func WithRaceDate(date time.Time) Option {
+	return func(p *Processor) {
+		p.raceDate = date
+	}
+}
+
+// WithMode sets how ProcessEvents reacts to invalid events. The default is Lenient.
+func WithMode(mode ProcessingMode) Option {
+	return func(p *Processor) {
+		p.mode = mode
+	}
+}
+
+// WithStartTolerance sets how long after the planned start time a competitor
+// may start without being disqualified. The default is the configuration's
+// StartTolerance, then its StartDelta, or one second if neither is set.
+func WithStartTolerance(d time.Duration) Option {
+	return func(p *Processor) {
+		p.startTolerance = d
+		p.toleranceSet = true
+	}
+}
+
+// WithStartDeltaOverrides gives the listed competitors a start window of their
+// own, taking precedence over the start tolerance.
+func WithStartDeltaOverrides(overrides map[int]time.Duration) Option {
+	return func(p *Processor) {
+		p.startDeltas = overrides
+	}
+}
+
+// WithClock sets the clock Finalize uses to decide whether the start window of
+// a competitor who never started has passed. The default is the race clock,
+// i.e. the time of the latest event applied.
+func WithClock(now func() time.Time) Option {
+	return func(p *Processor) {
+		p.now = now
+	}
+}
+
+// WithStrictOrdering rejects events stamped earlier than the last accepted
+// event. By default events are accepted in any order.
+func WithStrictOrdering(strict bool) Option {
+	return func(p *Processor) {
+		p.strictOrdering = strict
+	}
+}
+
+// WithStateValidation rejects events that are illegal transitions of the
+// competitor's state machine (see ValidateEvents), e.g. a hit without a
+// preceding arrival at the firing range. It is disabled by default.
+func WithStateValidation(enabled bool) Option {
+	return func(p *Processor) {
+		p.states = nil
+		if enabled {
+			p.states = newStateMachine(p.config)
+		}
+	}
+}
+
+// WithLeaderboard writes the live standings to w as newline-delimited JSON, one
+// LeaderboardUpdate after every completed lap. If writing fails, the error is
+// recorded as a warning and the leaderboard is not written any more.
+func WithLeaderboard(w io.Writer) Option {
+	return func(p *Processor) {
+		encoder := json.NewEncoder(w)
+		failed := false
+		p.OnEvent(10, func(event EventLog, competitor *Competitor) {
+			if failed {
+				return
+			}
+			err := encoder.Encode(LeaderboardUpdate{
+				Time:         formatTime(event.Time),
+				CompetitorID: competitor.ID,
+				Standings:    BuildLeaderboard(p.competitors),
+			})
+			if err != nil {
+				failed = true
+				p.warnf(event, "writing the leaderboard failed, no more updates are written: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package biathlon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithStartTolerance(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:50:00.000] 2 1 10:00:00.000",
+		"[10:00:20.000] 4 1",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if competitors[1].Status != "Disqualified" {
+		t.Errorf("Default tolerance: expected Disqualified, got %s", competitors[1].Status)
+	}
+
+	competitors, _, _, err = ProcessEvents(context.Background(), events, config, WithMode(Strict), WithStartTolerance(30*time.Second))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if competitors[1].Status != "Started" {
+		t.Errorf("30s tolerance: expected Started, got %s", competitors[1].Status)
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:50:00.000] 2 1 10:00:00.000",
+	})
+
+	beforeStart, _ := parseTime("[09:59:00.000]")
+	competitors, outgoing, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict),
+		WithClock(func() time.Time { return beforeStart }))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if competitors[1].Status != "NotStarted" || len(outgoing) != 0 {
+		t.Errorf("Clock before start window: expected NotStarted without outgoing events, got %s, %v",
+			competitors[1].Status, outgoing)
+	}
+
+	afterStart, _ := parseTime("[10:05:00.000]")
+	competitors, outgoing, _, err = ProcessEvents(context.Background(), events, config, WithMode(Strict),
+		WithClock(func() time.Time { return afterStart }))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if competitors[1].Status != "Disqualified" || len(outgoing) != 1 {
+		t.Errorf("Clock after start window: expected Disqualified with one outgoing event, got %s, %v",
+			competitors[1].Status, outgoing)
+	}
+	if reason := competitors[1].DisqualificationReason; reason != "did not start within allowed window: planned 10:00:00.000" {
+		t.Errorf("Clock after start window: unexpected disqualification reason %q", reason)
+	}
+}
+
+func TestFinalizeUsesRaceClock(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	// The last event is stamped before competitor 1's start window closes,
+	// so the sweep must not disqualify them whatever the wall time is
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:31:00.000] 1 2",
+		"[09:50:00.000] 2 1 10:00:00.000",
+		"[09:51:00.000] 2 2 09:40:00.000",
+	})
+
+	competitors, outgoing, _, err := ProcessEvents(context.Background(), events, config,
+		WithMode(Strict), WithStartTolerance(30*time.Second))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if competitors[1].Status != "NotStarted" {
+		t.Errorf("Expected competitor 1 to stay NotStarted, got %s", competitors[1].Status)
+	}
+	if competitors[2].Status != "Disqualified" || len(outgoing) != 1 {
+		t.Errorf("Expected only competitor 2 disqualified, got %s, %v", competitors[2].Status, outgoing)
+	}
+}
+
+func TestFinalizeDisqualifiesInOrder(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	var lines []string
+	for id := 1; id <= 8; id++ {
+		lines = append(lines, fmt.Sprintf("[09:30:00.000] 1 %d", id), fmt.Sprintf("[09:40:00.000] 2 %d 10:00:00.000", id))
+	}
+	p := NewProcessor(config, WithClock(func() time.Time {
+		now, _ := parseTime("[11:00:00.000]")
+		return now
+	}))
+	for _, event := range parseEvents(t, lines) {
+		if err := p.AddEvent(event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	p.Finalize()
+
+	var ids []int
+	for _, event := range p.OutgoingEvents() {
+		ids = append(ids, event.CompetitorID)
+	}
+	if expected := []int{1, 2, 3, 4, 5, 6, 7, 8}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected disqualifications for %v in order, got %v", expected, ids)
+	}
+}
+
+func TestWithPlannedStartTimes(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, Start: "10:00:00.000", StartDelta: "00:00:30"}
+
+	pursuitStart, _ := parseTime("[10:01:04.116]")
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:31:00.000] 1 2",
+		"[09:40:00.000] 2 1 10:00:00.000",
+		"[09:40:00.000] 2 2 10:00:30.000",
+	})
+
+	p := NewProcessor(config, WithPlannedStartTimes(map[int]time.Time{1: pursuitStart}))
+	for _, event := range events {
+		if err := p.AddEvent(event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	competitors := p.Results()
+	if got := formatTime(competitors[1].PlannedStartTime); got != "10:01:04.116" {
+		t.Errorf("Expected the pursuit start 10:01:04.116 to win over the draw, got %s", got)
+	}
+	if got := formatTime(competitors[2].PlannedStartTime); got != "10:00:30.000" {
+		t.Errorf("Expected the drawn start 10:00:30.000 without a pursuit start, got %s", got)
+	}
+	if len(p.Warnings()) != 1 {
+		t.Errorf("Expected one warning about the ignored draw, got %v", p.Warnings())
+	}
+}
+
+func TestWithStrictOrdering(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:05.000] 1 2",
+		"[09:30:01.000] 3 1",
+	})
+
+	if _, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict)); err != nil {
+		t.Errorf("Default ordering: unexpected error: %v", err)
+	}
+
+	_, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict), WithStrictOrdering(true))
+
+	var eventErr *EventError
+	if !errors.As(err, &eventErr) {
+		t.Fatalf("Strict ordering: expected an EventError, got %v", err)
+	}
+
+	if eventErr.Event.EventID != 3 {
+		t.Errorf("Strict ordering: expected event 3 to be rejected, got %+v", eventErr.Event)
+	}
+}
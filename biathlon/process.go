@@ -0,0 +1,598 @@
+package biathlon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Processor applies events one at a time, so results can be inspected while
+// the race is still running.
+type Processor struct {
+	config      Configuration
+	competitors map[int]*Competitor
+	emitted     []OutgoingEvent
+	warnings    []ValidationWarning
+	lastEvent   time.Time
+	bestSplits  map[splitKey]time.Duration
+	registered  int
+	history     map[int][]EventLog // accepted events per competitor, for corrections
+	replaying   bool               // rebuilding a competitor, mutes commentary and outgoing events
+
+	eventHandlers    map[int][]EventHandler
+	anyEventHandlers []EventHandler
+	statusHandlers   []StatusChangeHandler
+
+	logger         *slog.Logger
+	outgoing       io.Writer
+	names          map[int]string
+	startList      []StartListEntry
+	plannedStarts  map[int]time.Time
+	mode           ProcessingMode
+	startTolerance time.Duration
+	toleranceSet   bool // startTolerance was given with WithStartTolerance
+	startDeltas    map[int]time.Duration
+	firstStart     time.Time
+	startInterval  time.Duration
+	raceDate       time.Time
+	race           RaceState
+	now            func() time.Time
+	strictOrdering bool
+	states         *stateMachine
+}
+
+// NewProcessor returns a Processor for config customized by opts.
+func NewProcessor(config Configuration, opts ...Option) *Processor {
+	p := &Processor{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.reset(config)
+
+	if p.logger == nil {
+		p.logger = slog.New(NewNarrationHandler(io.Discard, nil))
+	}
+	if p.outgoing == nil {
+		p.outgoing = io.Discard
+	}
+
+	return p
+}
+
+// Reset prepares p for another race: it loads the configuration at
+// configPath like LoadConfiguration, applies the BIATHLON_* environment and
+// forgets all competitors, outgoing events and warnings. The options and
+// hooks p was created with are kept. If the new configuration cannot be
+// loaded or is invalid, p is left unchanged.
+func (p *Processor) Reset(configPath string) error {
+	config, _, err := LoadConfiguration(configPath, "")
+	if err != nil {
+		return err
+	}
+	if err := config.ApplyEnv(); err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	p.reset(config)
+	return nil
+}
+
+// Config returns the configuration of the race being processed.
+func (p *Processor) Config() Configuration {
+	return p.config
+}
+
+// reset starts a new race for config.
+func (p *Processor) reset(config Configuration) {
+	p.config = config
+	p.competitors = make(map[int]*Competitor)
+	p.emitted = nil
+	p.warnings = nil
+	p.lastEvent = time.Time{}
+	p.bestSplits = make(map[splitKey]time.Duration)
+	p.registered = 0
+	p.history = make(map[int][]EventLog)
+	p.race = RaceState{}
+	for _, entry := range p.startList {
+		name := entry.Name
+		if name == "" {
+			name = p.names[entry.ID]
+		}
+		competitor := newCompetitor(entry.ID, name, time.Time{})
+		competitor.Nation = entry.Nation
+		competitor.Bib = entry.Bib
+		p.competitors[entry.ID] = competitor
+	}
+	if p.states != nil {
+		p.states = newStateMachine(config)
+	}
+
+	// Competitors may start any time within StartDelta of their planned start,
+	// unless the configuration sets a tolerance of its own
+	if !p.toleranceSet {
+		p.startTolerance = 1 * time.Second
+		if startDelta, err := parseDuration(config.StartDelta); err == nil {
+			p.startTolerance = startDelta
+		}
+		if tolerance, err := parseDuration(config.StartTolerance); err == nil {
+			p.startTolerance = tolerance
+		}
+	}
+
+	// Without a draw, competitors start StartDelta apart from Start in the
+	// order they registered, or all at Start in a mass start
+	p.firstStart = time.Time{}
+	if firstStart, err := parseTime(config.Start); err == nil {
+		p.firstStart = firstStart
+	}
+	p.startInterval = 0
+	if startInterval, err := parseDuration(config.StartDelta); err == nil {
+		p.startInterval = startInterval
+	}
+}
+
+// startWindow returns how far from its planned start the competitor may
+// start: its override, if any, or the start tolerance.
+func (p *Processor) startWindow(competitorID int) time.Duration {
+	if delta, ok := p.startDeltas[competitorID]; ok {
+		return delta
+	}
+
+	return p.startTolerance
+}
+
+// splitKey identifies a checkpoint on a particular lap.
+type splitKey struct {
+	lap, checkpoint int
+}
+
+// cancelCheckInterval is how many events or lines are handled between checks
+// for context cancellation.
+const cancelCheckInterval = 256
+
+// ProcessingMode controls how ProcessEvents reacts to invalid events.
+type ProcessingMode int
+
+const (
+	// Lenient skips invalid events, reports them all and keeps processing.
+	Lenient ProcessingMode = iota
+	// Strict stops at the first invalid event.
+	Strict
+)
+
+// EventError identifies the event that could not be processed.
+type EventError struct {
+	Event EventLog
+	Err   error
+}
+
+func (e *EventError) Error() string {
+	return fmt.Sprintf("[%s] event %d for competitor(%d): %v",
+		formatTime(e.Event.Time), e.Event.EventID, e.Event.CompetitorID, e.Err)
+}
+
+func (e *EventError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationWarning describes an accepted event that looks out of place, such
+// as a second registration or a start well before the planned start time.
+type ValidationWarning struct {
+	Event   EventLog
+	Message string
+}
+
+func (w ValidationWarning) String() string {
+	return fmt.Sprintf("[%s] event %d for competitor(%d): %s",
+		formatTime(w.Event.Time), w.Event.EventID, w.Event.CompetitorID, w.Message)
+}
+
+// ProcessEvents applies the events in order and returns the resulting
+// competitors keyed by ID together with the outgoing events in the order they
+// were generated and the warnings about events that were accepted but look out
+// of place. Invalid events are returned as *EventError values: in Strict mode
+// processing stops at the first one and the state reached so far is returned,
+// in Lenient mode they are skipped and returned joined once all events are
+// processed.
+//
+// If ctx is cancelled, ProcessEvents returns ctx.Err() together with the
+// provisional state after the events processed so far; Finalize is not run.
+func ProcessEvents(ctx context.Context, events []EventLog, config Configuration, opts ...Option) (map[int]*Competitor, []OutgoingEvent, []ValidationWarning, error) {
+	p := NewProcessor(config, opts...)
+	err := p.AddEvents(ctx, events)
+	if err != nil && (p.mode == Strict || err == ctx.Err()) {
+		return p.Results(), p.OutgoingEvents(), p.Warnings(), err
+	}
+
+	competitors := p.Finalize()
+	return competitors, p.OutgoingEvents(), p.Warnings(), err
+}
+
+// AddEvents applies the events in order like ProcessEvents, but leaves calling
+// Finalize to the caller.
+func (p *Processor) AddEvents(ctx context.Context, events []EventLog) error {
+	var errs []error
+	for i, event := range events {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if err := p.AddEvent(event); err != nil {
+			if p.mode == Strict {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Results returns the current competitor state keyed by ID.
+func (p *Processor) Results() map[int]*Competitor {
+	return p.competitors
+}
+
+// OutgoingEvents returns the outgoing events generated so far.
+func (p *Processor) OutgoingEvents() []OutgoingEvent {
+	return p.emitted
+}
+
+// Warnings returns the anomalies detected in the events processed so far.
+func (p *Processor) Warnings() []ValidationWarning {
+	return p.warnings
+}
+
+// warnf records an anomaly about event and logs it at warning level.
+func (p *Processor) warnf(event EventLog, format string, args ...any) {
+	if p.replaying {
+		return
+	}
+	warning := ValidationWarning{Event: event, Message: fmt.Sprintf(format, args...)}
+	p.warnings = append(p.warnings, warning)
+	p.logf(slog.LevelWarn, event, "Warning: %s", warning.Message)
+}
+
+// logf logs a commentary line about event. The record is stamped with the
+// event time rather than the wall clock.
+func (p *Processor) logf(level slog.Level, event EventLog, format string, args ...any) {
+	ctx := context.Background()
+	if p.replaying || !p.logger.Enabled(ctx, level) {
+		return
+	}
+
+	r := slog.NewRecord(event.Time, level, fmt.Sprintf(format, args...), 0)
+	r.AddAttrs(
+		slog.Int("competitorID", event.CompetitorID),
+		slog.Int("eventID", event.EventID),
+	)
+	_ = p.logger.Handler().Handle(ctx, r)
+}
+
+func (p *Processor) emit(t time.Time, eventID, competitorID int) {
+	if p.replaying {
+		return
+	}
+	event := OutgoingEvent{
+		Time:         t,
+		EventID:      eventID,
+		CompetitorID: competitorID,
+	}
+	p.emitted = append(p.emitted, event)
+	fmt.Fprintln(p.outgoing, event)
+}
+
+// errIgnored is returned by the event handlers for an event that is warned
+// about and otherwise dropped, e.g. a duplicate event 10.
+var errIgnored = errors.New("event ignored")
+
+// AddEvent applies a single event. Events that are malformed or impossible for
+// the competitor's current state are rejected with an *EventError and leave the
+// state untouched.
+func (p *Processor) AddEvent(event EventLog) error {
+	if err := p.applyEvent(event); err != nil {
+		return &EventError{Event: event, Err: err}
+	}
+
+	return nil
+}
+
+func (p *Processor) applyEvent(event EventLog) error {
+	if p.lastEvent.IsZero() && !p.raceDate.IsZero() {
+		event.Time = onDate(event.Time, p.raceDate)
+	} else {
+		event.Time = nearestDay(event.Time, p.lastEvent)
+	}
+
+	// Outgoing events written back to an events file as an audit log are
+	// regenerated from the incoming ones, not applied
+	if event.EventID == EventDisqualified || event.EventID == EventFinished {
+		p.warnf(event, "outgoing event %d in the input is ignored", event.EventID)
+		return nil
+	}
+
+	if p.strictOrdering && !p.lastEvent.IsZero() && event.Time.Before(p.lastEvent) {
+		return fmt.Errorf("event is earlier than the previous event at %s", formatTime(p.lastEvent))
+	}
+
+	// A re-measurement is about the course, not a competitor
+	if event.EventID == 16 {
+		if err := p.remeasure(event); err != nil {
+			return err
+		}
+		p.advanceClock(event.Time)
+		return nil
+	}
+
+	// The start gun starts every competitor still waiting to start
+	if event.EventID == 17 {
+		if err := p.fireStartGun(event); err != nil {
+			return err
+		}
+		p.advanceClock(event.Time)
+		return nil
+	}
+
+	if err := p.applyToCompetitor(event); err != nil {
+		return err
+	}
+
+	p.advanceClock(event.Time)
+
+	return nil
+}
+
+// applyToCompetitor applies event to its competitor and notifies the hooks.
+func (p *Processor) applyToCompetitor(event EventLog) error {
+	if p.states != nil {
+		if err := p.states.check(event); err != nil {
+			return err
+		}
+	}
+
+	oldStatus := ""
+	if competitor, exists := p.competitors[event.CompetitorID]; exists {
+		oldStatus = competitor.Status
+	}
+
+	// An ignored event is warned about but otherwise as if it never came:
+	// it doesn't move the competitor's state, isn't replayed by a correction
+	// and isn't passed to the hooks
+	if err := p.applyCompetitorEvent(event); errors.Is(err, errIgnored) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if p.states != nil {
+		p.states.apply(event)
+	}
+	if event.EventID != 14 {
+		p.history[event.CompetitorID] = append(p.history[event.CompetitorID], event)
+	}
+
+	competitor := p.competitors[event.CompetitorID]
+	p.notifyEvent(event, competitor)
+	p.notifyStatusChange(competitor, oldStatus)
+
+	return nil
+}
+
+// advanceClock moves the race clock to t unless it is already past it.
+func (p *Processor) advanceClock(t time.Time) {
+	if p.lastEvent.IsZero() || t.After(p.lastEvent) {
+		p.lastEvent = t
+	}
+}
+
+func (p *Processor) applyCompetitorEvent(event EventLog) error {
+	competitorID := event.CompetitorID
+
+	competitor, exists := p.competitors[competitorID]
+	if !exists {
+		if event.EventID != 1 {
+			return errors.New("competitor is not registered")
+		}
+
+		competitor = newCompetitor(competitorID, p.names[competitorID], time.Time{})
+		p.competitors[competitorID] = competitor
+		if p.startList != nil {
+			p.warnf(event, "%s is not on the start list", competitor.Label())
+		}
+	}
+
+	// Competitors on the start list are known before their registration,
+	// which confirms them
+	newlyRegistered := event.EventID == 1 && competitor.RegisteredTime.IsZero()
+	plannedFrom := ""
+	if newlyRegistered {
+		competitor.RegisteredTime = event.Time
+		if plannedStart, ok := p.plannedStarts[competitorID]; ok {
+			competitor.PlannedStartTime = nearestDay(plannedStart, event.Time)
+			plannedFrom = "the given start times"
+		} else if !p.firstStart.IsZero() && p.config.MassStart {
+			competitor.PlannedStartTime = nearestDay(p.firstStart, event.Time)
+			plannedFrom = "the mass start"
+		} else if !p.firstStart.IsZero() {
+			competitor.PlannedStartTime = nearestDay(p.firstStart.Add(time.Duration(p.registered)*p.startInterval), event.Time)
+			plannedFrom = fmt.Sprintf("registration order %d and the %s start interval", p.registered+1, p.startInterval)
+		}
+		p.registered++
+	}
+
+	// A disqualified competitor may keep racing and the sensors may keep
+	// reporting a competitor who can't continue, but neither competes any more
+	if isRaceEvent(event.EventID) {
+		switch competitor.Status {
+		case "Disqualified":
+			p.logf(slog.LevelInfo, event, "The %s is disqualified, event %d ignored", competitor.Label(), event.EventID)
+			return errIgnored
+		case "NotFinished", "Withdrew":
+			p.warnf(event, "%s can`t continue, event %d ignored", competitor.Label(), event.EventID)
+			return errIgnored
+		}
+	}
+
+	switch event.EventID {
+	case 1: // Registration
+		if !newlyRegistered {
+			p.warnf(event, "%s is already registered", competitor.Label())
+			return errIgnored
+		}
+		if exists {
+			p.logf(slog.LevelInfo, event, "The %s confirmed the registration", competitor.Label())
+		} else {
+			p.logf(slog.LevelInfo, event, "The %s registered", competitor.Label())
+		}
+		if plannedFrom != "" {
+			p.logf(slog.LevelDebug, event, "The planned start time of the %s is %s, from %s",
+				competitor.Label(), formatTime(competitor.PlannedStartTime), plannedFrom)
+		}
+
+	case 2: // Start time set by draw
+		startTimeStr := event.ExtraParams
+		plannedStartTime, err := parseTime(startTimeStr)
+		if err != nil {
+			return fmt.Errorf("invalid start time %q: %w", startTimeStr, err)
+		}
+		if _, ok := p.plannedStarts[competitorID]; ok {
+			p.warnf(event, "%s starts at %s, ignoring the drawn start time %s",
+				competitor.Label(), formatTime(competitor.PlannedStartTime), startTimeStr)
+			return errIgnored
+		}
+		if p.config.MassStart {
+			p.warnf(event, "%s starts with the mass start at %s, ignoring the drawn start time %s",
+				competitor.Label(), p.config.Start, startTimeStr)
+			return errIgnored
+		}
+		competitor.PlannedStartTime = nearestDay(plannedStartTime, event.Time)
+		p.logf(slog.LevelInfo, event, "The start time for the %s was set by a draw to %s",
+			competitor.Label(), startTimeStr)
+
+	case 3: // Competitor on start line
+		p.logf(slog.LevelInfo, event, "The %s is on the start line", competitor.Label())
+
+	case 4: // Competitor started
+		return p.startCompetitor(competitor, event)
+
+	case 5: // Competitor on firing range
+		return p.enterFiringRange(competitor, event)
+
+	case 6: // Target hit
+		return p.hitTarget(competitor, event)
+
+	case 7: // Competitor left firing range
+		return p.leaveFiringRange(competitor, event)
+
+	case 8: // Competitor entered penalty laps
+		if len(competitor.PenaltyStartTimes) > len(competitor.PenaltyEndTimes) {
+			return errors.New("entered the penalty laps while already on them")
+		}
+		competitor.PenaltyStartTimes = append(competitor.PenaltyStartTimes, event.Time)
+		p.logf(slog.LevelInfo, event, "The %s entered the penalty laps", competitor.Label())
+
+	case 9: // Competitor left penalty laps
+		return p.leavePenaltyLaps(competitor, event)
+
+	case 10: // Competitor ended main lap
+		return p.endLap(competitor, event)
+
+	case 11: // Competitor can't continue
+		competitor.Status = "NotFinished"
+		if strings.HasPrefix(event.ExtraParams, "WD:") || strings.HasPrefix(event.ExtraParams, "withdrew") {
+			competitor.Status = "Withdrew"
+		}
+		competitor.DNFReason = event.ExtraParams
+		competitor.DNFTime = event.Time
+		p.leaveRange(competitor.ID)
+		p.logf(slog.LevelWarn, event, "The %s can`t continue: %s", competitor.Label(), event.ExtraParams)
+
+	case 12: // Competitor resumed after a technical issue
+		return p.resume(competitor, event)
+
+	case 13: // Competitor passed an intermediate checkpoint
+		return p.passCheckpoint(competitor, event)
+
+	case 14: // Official time correction
+		return p.correctEvent(competitor, event)
+
+	case 15: // Target missed
+		return p.missTarget(competitor, event)
+
+	case 17: // Competitor started by the start gun
+		return p.startWithGun(competitor, event)
+
+	case 18: // Competitor received the relay baton
+		return p.receiveBaton(competitor, event)
+
+	case 19: // Competitor handed off the relay baton
+		return p.handOffBaton(competitor, event)
+
+	default:
+		return errors.New("unknown event ID")
+	}
+
+	return nil
+}
+
+// isRaceEvent reports whether eventID is progress on the course or the range.
+func isRaceEvent(eventID int) bool {
+	switch eventID {
+	case 5, 6, 7, 8, 9, 10, 11, 13, 15:
+		return true
+	}
+
+	return false
+}
+
+// Finalize disqualifies competitors whose start window has passed by the race
+// clock without them starting and returns the final competitor state.
+func (p *Processor) Finalize() map[int]*Competitor {
+	now := p.lastEvent
+	if p.now != nil {
+		now = p.now()
+	}
+
+	// Disqualify in ID order so the events, narration and hooks are repeatable
+	ids := make([]int, 0, len(p.competitors))
+	for id := range p.competitors {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		competitor := p.competitors[id]
+		if competitor.Status == "NotStarted" && !competitor.PlannedStartTime.IsZero() {
+			deadline := competitor.PlannedStartTime.Add(p.startWindow(competitor.ID))
+			if now.After(deadline) {
+				oldStatus := competitor.Status
+				competitor.Status = "Disqualified"
+				competitor.DisqualificationReason = fmt.Sprintf("did not start within allowed window: planned %s",
+					formatTime(competitor.PlannedStartTime))
+				disqualification := EventLog{
+					Time:         deadline,
+					EventID:      EventDisqualified,
+					CompetitorID: competitor.ID,
+				}
+				p.logf(slog.LevelWarn, disqualification, "The %s is disqualified", competitor.Label())
+				p.logf(slog.LevelDebug, disqualification, "The %s is disqualified because they %s",
+					competitor.Label(), competitor.DisqualificationReason)
+
+				p.emit(disqualification.Time, EventDisqualified, competitor.ID)
+				p.notifyStatusChange(competitor, oldStatus)
+			}
+		}
+	}
+
+	return p.competitors
+}
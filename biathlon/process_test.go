@@ -0,0 +1,1870 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func narrationLogger(w io.Writer) *slog.Logger {
+	return slog.New(NewNarrationHandler(w, nil))
+}
+
+func parseEvents(t *testing.T, lines []string) []EventLog {
+	t.Helper()
+
+	events := make([]EventLog, 0, len(lines))
+	for _, line := range lines {
+		event, err := ParseEventLog(line)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %s: %v", line, err)
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func TestProcessEvents(t *testing.T) {
+	config := Configuration{
+		Laps:        1,
+		LapLen:      3651,
+		PenaltyLen:  50,
+		FiringLines: 1,
+		Start:       "09:30:00.000",
+		StartDelta:  "00:00:30",
+	}
+
+	events := parseEvents(t, []string{
+		"[09:05:59.867] 1 1",
+		"[09:15:00.841] 2 1 09:30:00.000",
+		"[09:29:45.734] 3 1",
+		"[09:30:00.500] 4 1",
+		"[09:49:31.659] 5 1 1",
+		"[09:49:33.123] 6 1 1",
+		"[09:49:38.339] 7 1",
+		"[09:59:03.872] 10 1",
+	})
+
+	var out, outgoing bytes.Buffer
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config,
+		WithLogger(narrationLogger(&out)), WithOutgoing(&outgoing), WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	competitor, ok := competitors[1]
+	if !ok {
+		t.Fatalf("Expected competitor 1 to be registered")
+	}
+
+	if competitor.Status != "Finished" {
+		t.Errorf("Expected status Finished, got %s", competitor.Status)
+	}
+
+	if len(competitor.LapTimes) != 1 {
+		t.Errorf("Expected 1 lap time, got %d", len(competitor.LapTimes))
+	}
+
+	if competitor.Hits != 1 {
+		t.Errorf("Expected 1 hit, got %d", competitor.Hits)
+	}
+
+	expectedLog := strings.Join([]string{
+		"[09:05:59.867] The competitor(1) registered",
+		"[09:15:00.841] The start time for the competitor(1) was set by a draw to 09:30:00.000",
+		"[09:29:45.734] The competitor(1) is on the start line",
+		"[09:30:00.500] The competitor(1) has started",
+		"[09:49:31.659] The competitor(1) is on the firing range(1)",
+		"[09:49:33.123] The target(1) has been hit by competitor(1)",
+		"[09:49:38.339] The competitor(1) left the firing range",
+		"[09:59:03.872] The competitor(1) has finished",
+		"[09:59:03.872] The competitor(1) ended the main lap",
+	}, "\n") + "\n"
+
+	if out.String() != expectedLog {
+		t.Errorf("Expected log:\n%s\ngot:\n%s", expectedLog, out.String())
+	}
+
+	if outgoing.String() != "[09:59:03.872] 33 1\n" {
+		t.Errorf("Expected outgoing finish event, got %q", outgoing.String())
+	}
+}
+
+func TestProcessorMatchesBatch(t *testing.T) {
+	config := Configuration{
+		Laps:        2,
+		LapLen:      3500,
+		PenaltyLen:  150,
+		FiringLines: 1,
+		Start:       "10:00:00.000",
+		StartDelta:  "00:01:30",
+	}
+
+	events := parseEvents(t, []string{
+		"[09:31:49.285] 1 1",
+		"[09:32:17.531] 1 2",
+		"[09:55:00.000] 2 1 10:00:00.000",
+		"[09:56:30.000] 2 2 10:01:30.000",
+		"[10:00:00.500] 4 1",
+		"[10:01:30.800] 4 2",
+		"[10:08:00.000] 8 1",
+		"[10:09:00.000] 9 1",
+		"[10:12:00.000] 10 1",
+		"[10:14:00.000] 11 2 Lost in the forest",
+		"[10:24:00.000] 10 1",
+	})
+
+	batch, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	p := NewProcessor(config)
+	for i, event := range events {
+		if err := p.AddEvent(event); err != nil {
+			t.Fatalf("Unexpected error for event %d: %v", i, err)
+		}
+
+		if i == 8 {
+			mid := p.Results()[1]
+			if mid.Status != "Started" || len(mid.LapTimes) != 1 {
+				t.Errorf("Expected competitor 1 mid-race with 1 lap, got status %s with %d laps",
+					mid.Status, len(mid.LapTimes))
+			}
+		}
+	}
+	incremental := p.Finalize()
+
+	for id, expected := range batch {
+		got, ok := incremental[id]
+		if !ok {
+			t.Fatalf("Competitor %d missing from incremental results", id)
+		}
+
+		if got.Status != expected.Status || len(got.LapTimes) != len(expected.LapTimes) ||
+			got.TotalPenaltyTime != expected.TotalPenaltyTime || !got.FinishTime.Equal(expected.FinishTime) {
+			t.Errorf("Competitor %d: incremental state %+v differs from batch %+v", id, got, expected)
+		}
+	}
+}
+
+func TestProcessEventsConcurrently(t *testing.T) {
+	config, _, err := LoadConfiguration("../sunny_5_skiers/config.json", "")
+	if err != nil {
+		t.Fatalf("Unexpected error loading sample configuration: %v", err)
+	}
+	eventsFile, err := os.Open("../sunny_5_skiers/events")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sample events: %v", err)
+	}
+	defer eventsFile.Close()
+	events, err := ReadEvents(context.Background(), eventsFile)
+	if err != nil {
+		t.Fatalf("Unexpected error reading sample events: %v", err)
+	}
+
+	var expected bytes.Buffer
+	if _, _, _, err := ProcessEvents(context.Background(), events, config, WithOutput(&expected)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(expected.String(), "The competitor(1) registered\n") ||
+		!strings.Contains(expected.String(), "] 33 1\n") {
+		t.Fatalf("Expected the commentary and outgoing events in the output, got:\n%s", expected.String())
+	}
+
+	// Every run writes to its own buffer, so parallel runs don't interleave
+	for i := 0; i < 4; i++ {
+		t.Run(fmt.Sprintf("run %d", i), func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			if _, _, _, err := ProcessEvents(context.Background(), events, config, WithOutput(&out)); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out.String() != expected.String() {
+				t.Errorf("Expected the same output as a single run, got:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestProcessorRejectsImpossibleEvents(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	tests := []struct {
+		name  string
+		setup []string
+		event string
+	}{
+		{"unregistered competitor", nil, "[10:00:00.000] 4 1"},
+		{"penalty entry twice", []string{"[09:00:00.000] 1 1", "[09:30:00.000] 8 1"}, "[10:00:00.000] 8 1"},
+		{"lap end before start", []string{"[09:00:00.000] 1 1"}, "[10:00:00.000] 10 1"},
+		{"unknown event", []string{"[09:00:00.000] 1 1"}, "[10:00:00.000] 42 1"},
+		{"malformed start time", []string{"[09:00:00.000] 1 1"}, "[09:30:00.000] 2 1 10:00"},
+		{"malformed firing range", []string{"[09:00:00.000] 1 1"}, "[10:00:00.000] 5 1 first"},
+		{"malformed target", []string{"[09:00:00.000] 1 1"}, "[10:00:00.000] 6 1 x"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		p := NewProcessor(config, WithLogger(narrationLogger(&out)), WithOutgoing(&out))
+		for _, event := range parseEvents(t, test.setup) {
+			if err := p.AddEvent(event); err != nil {
+				t.Fatalf("%s: unexpected setup error: %v", test.name, err)
+			}
+		}
+
+		logLen := out.Len()
+		event := parseEvents(t, []string{test.event})[0]
+		if err := p.AddEvent(event); err == nil {
+			t.Errorf("%s: expected error, got none", test.name)
+		}
+
+		if out.Len() != logLen {
+			t.Errorf("%s: rejected event should not produce output", test.name)
+		}
+
+		if competitor, ok := p.Results()[1]; ok {
+			if len(competitor.PenaltyTimes) != 0 || len(competitor.LapTimes) != 0 {
+				t.Errorf("%s: rejected event mutated competitor state: %+v", test.name, competitor)
+			}
+		}
+	}
+}
+
+func TestProcessEventsOutgoing(t *testing.T) {
+	config := Configuration{
+		Laps:       1,
+		LapLen:     3500,
+		PenaltyLen: 150,
+		Start:      "10:00:00.000",
+		StartDelta: "00:01:30",
+	}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:30:02.000] 1 3",
+		"[09:50:00.000] 2 1 10:00:00.000",
+		"[09:50:01.000] 2 2 10:01:30.000",
+		"[10:00:00.500] 4 1",
+		"[10:03:05.000] 4 2",
+		"[10:12:00.000] 10 1",
+		"[10:13:00.000] 11 3 Broken ski",
+	})
+
+	_, outgoing, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"[10:03:05.000] 32 2",
+		"[10:12:00.000] 33 1",
+	}
+
+	if len(outgoing) != len(expected) {
+		t.Fatalf("Expected %d outgoing events, got %d: %v", len(expected), len(outgoing), outgoing)
+	}
+
+	for i := range expected {
+		if outgoing[i].String() != expected[i] {
+			t.Errorf("Outgoing event %d: expected %q, got %q", i, expected[i], outgoing[i].String())
+		}
+	}
+
+	if outgoing[0].EventID != EventDisqualified || outgoing[0].CompetitorID != 2 {
+		t.Errorf("Expected disqualification of competitor 2, got %+v", outgoing[0])
+	}
+
+	if outgoing[1].EventID != EventFinished || outgoing[1].CompetitorID != 1 {
+		t.Errorf("Expected finish of competitor 1, got %+v", outgoing[1])
+	}
+}
+
+func TestProcessEventsCorruptStartTime(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:50:00.000] 2 1 10:00:xx",
+		"[09:50:01.000] 2 2 10:01:30.000",
+		"[10:00:00.500] 4 1",
+		"[10:01:30.500] 4 2",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	var eventErr *EventError
+	if !errors.As(err, &eventErr) {
+		t.Fatalf("Strict: expected an EventError, got %v", err)
+	}
+
+	if eventErr.Event.EventID != 2 || eventErr.Event.CompetitorID != 1 {
+		t.Errorf("Strict: expected error for event 2 of competitor 1, got %+v", eventErr.Event)
+	}
+
+	if !strings.HasPrefix(err.Error(), "[09:50:00.000] event 2 for competitor(1): ") {
+		t.Errorf("Strict: error should identify the event, got %q", err.Error())
+	}
+
+	if competitors[2].Status != "NotStarted" || !competitors[2].PlannedStartTime.IsZero() {
+		t.Errorf("Strict: processing should stop at the corrupt event, got %+v", competitors[2])
+	}
+
+	competitors, _, _, err = ProcessEvents(context.Background(), events, config, WithMode(Lenient))
+	if !errors.As(err, &eventErr) {
+		t.Fatalf("Lenient: expected an EventError, got %v", err)
+	}
+
+	if competitors[1].Status != "Started" {
+		t.Errorf("Lenient: competitor 1 without planned start should not be disqualified, got %s", competitors[1].Status)
+	}
+
+	if competitors[2].Status != "Started" {
+		t.Errorf("Lenient: processing should continue past the corrupt event, got %s", competitors[2].Status)
+	}
+}
+
+func TestProcessEventsNames(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+	})
+
+	var out bytes.Buffer
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config,
+		WithNames(map[int]string{1: "Anna Svensson"}), WithLogger(narrationLogger(&out)), WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if competitors[1].Name != "Anna Svensson" || competitors[2].Name != "" {
+		t.Errorf("Unexpected names: %q, %q", competitors[1].Name, competitors[2].Name)
+	}
+
+	expected := "[09:30:00.000] The competitor Anna Svensson(1) registered\n" +
+		"[09:30:01.000] The competitor(2) registered\n"
+	if out.String() != expected {
+		t.Errorf("Expected log:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestProcessEventsDebugNarration(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, FiringLines: 1, Start: "10:00:00.000", StartDelta: "00:01:30"}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:31:00.000] 1 2",
+		"[10:00:10.000] 4 1",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:10.000] 6 1 1",
+		"[10:05:30.000] 7 1",
+		"[10:05:35.000] 8 1",
+		"[10:07:05.000] 9 1",
+		"[10:05:00.000] 4 2",
+	})
+
+	var out bytes.Buffer
+	logger := slog.New(NewNarrationHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if _, _, _, err := ProcessEvents(context.Background(), events, config, WithLogger(logger)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"[09:31:00.000] The planned start time of the competitor(2) is 10:01:30.000, from registration order 2 and the 1m30s start interval\n",
+		"[10:07:05.000] The penalty laps of the competitor(1) from 10:05:35.000 to 10:07:05.000 (00:01:30.000) are booked to lap 1\n",
+		"[10:07:05.000] The penalty laps of the competitor(1) are matched to firing range 1, owing 4 loops\n",
+		"[10:05:00.000] The competitor(2) is disqualified because they started outside allowed window: planned 10:01:30.000, actual 10:05:00.000\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in the narration, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestProcessEventsLogLevelAndAttrs(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:13:00.000] 11 1 Broken ski",
+	})
+
+	var out bytes.Buffer
+	logger := slog.New(NewNarrationHandler(&out, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	if _, _, _, err := ProcessEvents(context.Background(), events, config, WithLogger(logger), WithMode(Strict)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "[10:13:00.000] The competitor(1) can`t continue: Broken ski\n"
+	if out.String() != expected {
+		t.Errorf("Expected only warnings %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	logger = slog.New(slog.NewJSONHandler(&out, nil))
+	if _, _, _, err := ProcessEvents(context.Background(), events, config, WithLogger(logger), WithMode(Strict)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	decoder := json.NewDecoder(&out)
+	var record struct {
+		Level        string `json:"level"`
+		Msg          string `json:"msg"`
+		CompetitorID int    `json:"competitorID"`
+		EventID      int    `json:"eventID"`
+	}
+	if err := decoder.Decode(&record); err != nil {
+		t.Fatalf("Unexpected error decoding log record: %v", err)
+	}
+
+	if record.Level != "INFO" || record.Msg != "The competitor(1) registered" ||
+		record.CompetitorID != 1 || record.EventID != 1 {
+		t.Errorf("Unexpected log record: %+v", record)
+	}
+}
+
+func TestProcessEventsPenaltyTimePerLap(t *testing.T) {
+	config := Configuration{Laps: 3, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 8 1",
+		"[10:06:00.000] 9 1",
+		"[10:10:00.000] 10 1",
+		"[10:20:00.000] 10 1",
+		"[10:25:00.000] 8 1",
+		"[10:25:30.000] 9 1",
+		"[10:26:00.000] 8 1",
+		"[10:26:45.000] 9 1",
+		"[10:28:00.000] 8 1",
+		"[10:30:00.000] 10 1",
+	})
+
+	var out bytes.Buffer
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict), WithLogger(narrationLogger(&out)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []time.Duration{time.Minute, 0, 75 * time.Second}
+	if !reflect.DeepEqual(competitors[1].PenaltyTimePerLap, expected) {
+		t.Errorf("Expected penalty time per lap %v, got %v", expected, competitors[1].PenaltyTimePerLap)
+	}
+
+	warning := "[10:30:00.000] The competitor(1) ended lap 3 without leaving the penalty laps\n"
+	if !strings.Contains(out.String(), warning) {
+		t.Errorf("Expected warning %q in log:\n%s", warning, out.String())
+	}
+}
+
+// cancelAfterContext reports cancellation once Err has been called more than limit times.
+type cancelAfterContext struct {
+	context.Context
+	calls int
+	limit int
+}
+
+func (c *cancelAfterContext) Err() error {
+	c.calls++
+	if c.calls > c.limit {
+		return context.Canceled
+	}
+
+	return nil
+}
+
+func TestProcessEventsCancelled(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	var events []EventLog
+	start, _ := parseTime("[09:00:00.000]")
+	for id := 1; id <= 2*cancelCheckInterval+10; id++ {
+		events = append(events, EventLog{Time: start.Add(time.Duration(id) * time.Second), EventID: 1, CompetitorID: id})
+		events = append(events, EventLog{Time: start.Add(time.Duration(id) * time.Second), EventID: 4, CompetitorID: id})
+	}
+
+	ctx := &cancelAfterContext{Context: context.Background(), limit: 2}
+	competitors, _, _, err := ProcessEvents(ctx, events, config, WithMode(Strict))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	// Two checks passed, so exactly two intervals of events were applied.
+	if len(competitors) != cancelCheckInterval {
+		t.Fatalf("Expected %d competitors after cancellation, got %d", cancelCheckInterval, len(competitors))
+	}
+
+	for id, competitor := range competitors {
+		if competitor.Status != "Started" || len(competitor.LapStartTimes) != 1 {
+			t.Errorf("Competitor %d has inconsistent partial state: %+v", id, competitor)
+		}
+	}
+}
+
+func TestReadEventsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	events, err := ReadEvents(ctx, strings.NewReader("[09:05:59.867] 1 1\n"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if len(events) != 0 {
+		t.Errorf("Expected no events after cancellation, got %d", len(events))
+	}
+}
+
+func TestProcessEventsStartDeltaWindow(t *testing.T) {
+	config := Configuration{
+		Laps:       1,
+		LapLen:     3500,
+		PenaltyLen: 150,
+		Start:      "10:00:00.000",
+		StartDelta: "00:01:30",
+	}
+
+	tests := []struct {
+		name     string
+		start    string
+		expected string
+	}{
+		{"exactly on time", "[10:00:00.000] 4 1", "Started"},
+		{"within delta", "[10:01:00.000] 4 1", "Started"},
+		{"at end of delta", "[10:01:30.000] 4 1", "Started"},
+		{"outside delta", "[10:01:30.001] 4 1", "Disqualified"},
+	}
+
+	for _, test := range tests {
+		events := parseEvents(t, []string{
+			"[09:30:00.000] 1 1",
+			"[09:50:00.000] 2 1 10:00:00.000",
+			test.start,
+		})
+
+		competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+
+		if competitors[1].Status != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, competitors[1].Status)
+		}
+	}
+}
+
+func TestProcessEventsConfiguredStartTolerance(t *testing.T) {
+	config := Configuration{
+		Laps:           1,
+		LapLen:         3500,
+		PenaltyLen:     150,
+		Start:          "10:00:00.000",
+		StartDelta:     "00:01:30",
+		StartTolerance: "00:00:30.000",
+	}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:30:02.000] 1 3",
+		"[09:50:00.000] 2 1 10:00:00.000",
+		"[09:50:01.000] 2 2 10:01:30.000",
+		"[09:50:02.000] 2 3 10:03:00.000",
+		"[10:00:30.000] 4 1",
+		"[10:02:00.001] 4 2",
+	})
+
+	clock := func() time.Time {
+		now, _ := parseTime("[10:03:30.001]")
+		return now
+	}
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict), WithClock(clock))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[int]string{1: "Started", 2: "Disqualified", 3: "Disqualified"}
+	for id, status := range expected {
+		if competitors[id].Status != status {
+			t.Errorf("Competitor %d: expected %s, got %s", id, status, competitors[id].Status)
+		}
+	}
+}
+
+func TestProcessEventsResume(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, GracePeriodSeconds: 60}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[10:00:00.000] 4 1",
+		"[10:00:30.000] 4 2",
+		"[10:05:00.000] 11 1 Broken pole",
+		"[10:05:45.000] 12 1 Pole replaced",
+		"[10:06:00.000] 11 2 Broken ski",
+		"[10:08:00.000] 12 2 Ski replaced",
+		"[10:12:00.000] 10 1",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config)
+
+	var eventErr *EventError
+	if !errors.As(err, &eventErr) || eventErr.Event.EventID != 12 || eventErr.Event.CompetitorID != 2 {
+		t.Fatalf("Expected resume of competitor 2 after the grace period to be rejected, got %v", err)
+	}
+
+	if competitors[1].Status != "Finished" || !competitors[1].Resumed || competitors[1].ResumeReason != "Pole replaced" {
+		t.Errorf("Expected competitor 1 to finish after resuming, got %+v", competitors[1])
+	}
+
+	if competitors[2].Status != "NotFinished" || competitors[2].Resumed {
+		t.Errorf("Expected competitor 2 to stay NotFinished, got %+v", competitors[2])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "[00:12:00.000] 1 [{00:12:00.000, 4.861}] {,} 0/0 +00:00.000 (resumed: Pole replaced)\n") {
+		t.Errorf("Expected resumed annotation in report:\n%s", buf.String())
+	}
+}
+
+func TestProcessEventsWarnings(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, StartDelta: "00:01:30"}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:31:00.000] 1 1",
+		"[09:50:00.000] 2 1 10:00:00.000",
+		"[09:50:01.000] 2 2 10:01:00.000",
+		"[10:00:00.000] 4 1",
+		"[09:58:00.000] 4 2",
+		"[10:00:05.000] 4 1",
+		"[10:12:00.000] 10 1",
+	})
+
+	var out bytes.Buffer
+	competitors, _, warnings, err := ProcessEvents(context.Background(), events, config, WithMode(Strict), WithLogger(narrationLogger(&out)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"[09:31:00.000] event 1 for competitor(1): competitor(1) is already registered",
+		"[09:58:00.000] event 4 for competitor(2): competitor(2) started before the planned start time 10:01:00.000",
+		"[10:00:05.000] event 4 for competitor(1): competitor(1) already started at 10:00:00.000",
+	}
+
+	if len(warnings) != len(expected) {
+		t.Fatalf("Expected %d warnings, got %d: %v", len(expected), len(warnings), warnings)
+	}
+
+	for i, warning := range warnings {
+		if warning.String() != expected[i] {
+			t.Errorf("Warning %d: expected %q, got %q", i, expected[i], warning.String())
+		}
+	}
+
+	if !strings.Contains(out.String(), "[09:31:00.000] Warning: competitor(1) is already registered\n") {
+		t.Errorf("Expected warning in commentary:\n%s", out.String())
+	}
+
+	competitor := competitors[1]
+	if competitor.Status != "Finished" || competitor.LapTimes[0] != 12*time.Minute {
+		t.Errorf("Expected the duplicate start to be ignored, got status %s with laps %v",
+			competitor.Status, competitor.LapTimes)
+	}
+}
+
+func TestProcessEventsRangeAccuracy(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, FiringLines: 2}
+
+	lines := []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:01.000] 6 1 1",
+		"[10:05:02.000] 6 1 2",
+		"[10:05:03.000] 6 1 4",
+		"[10:05:04.000] 7 1",
+		"[10:08:00.000] 5 1 2",
+	}
+	for target := 1; target <= 6; target++ {
+		lines = append(lines, fmt.Sprintf("[10:08:0%d.000] 6 1 %d", target, target))
+	}
+	lines = append(lines, "[10:08:10.000] 7 1")
+
+	p := NewProcessor(config)
+	for _, event := range parseEvents(t, lines) {
+		if err := p.AddEvent(event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	competitor := p.Results()[1]
+	if !reflect.DeepEqual(competitor.RangeAccuracy, []float64{0.6, 1.2}) {
+		t.Errorf("Expected range accuracy [0.6 1.2], got %v", competitor.RangeAccuracy)
+	}
+
+	warnings := p.Warnings()
+	if len(warnings) != 1 || warnings[0].String() != "[10:08:06.000] event 6 for competitor(1): competitor(1) hit more than 5 targets on firing range 2" {
+		t.Errorf("Expected one warning about too many hits, got %v", warnings)
+	}
+
+	entries := BuildReportEntries(p.Results(), config)
+	data, err := json.Marshal(entries[0])
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling entry: %v", err)
+	}
+
+	if !strings.Contains(string(data), `"rangeAccuracy":[0.6,1.2]`) {
+		t.Errorf("Expected range accuracy in JSON report, got %s", data)
+	}
+}
+
+func TestProcessEventsSplits(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, StartDelta: "00:01:30"}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:50:00.000] 2 1 10:00:00.000",
+		"[09:50:01.000] 2 2 10:01:30.000",
+		"[10:00:00.000] 4 1",
+		"[10:01:30.000] 4 2",
+		"[10:04:00.000] 13 1 1",
+		"[10:05:00.000] 13 2 1",
+		"[10:08:00.000] 13 2 2",
+		"[10:09:00.000] 13 1 2",
+		"[10:12:00.000] 10 1",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[int][]SplitTime{
+		1: {
+			{CheckpointID: 1, Lap: 1, At: events[6].Time, Elapsed: 4 * time.Minute},
+			{CheckpointID: 2, Lap: 1, At: events[9].Time, Elapsed: 9 * time.Minute, Gap: 2*time.Minute + 30*time.Second},
+		},
+		2: {
+			{CheckpointID: 1, Lap: 1, At: events[7].Time, Elapsed: 3*time.Minute + 30*time.Second},
+			{CheckpointID: 2, Lap: 1, At: events[8].Time, Elapsed: 6*time.Minute + 30*time.Second},
+		},
+	}
+
+	for id, splits := range expected {
+		if !reflect.DeepEqual(competitors[id].Splits, splits) {
+			t.Errorf("Competitor %d: expected splits %+v, got %+v", id, splits, competitors[id].Splits)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "    lap 1 checkpoint 2: 00:09:00.000 +00:02:30.000\n") {
+		t.Errorf("Expected split gap in text report:\n%s", buf.String())
+	}
+
+	var splits []SplitEntry
+	for _, entry := range BuildReportEntries(competitors, config) {
+		if entry.CompetitorID == 1 {
+			splits = entry.Splits
+		}
+	}
+
+	data, err := json.Marshal(splits[1])
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling split: %v", err)
+	}
+
+	expectedJSON := `{"checkpointID":2,"lap":1,"time":"10:09:00.000","elapsed":"00:09:00.000","gap":"00:02:30.000"}`
+	if string(data) != expectedJSON {
+		t.Errorf("Expected JSON %s, got %s", expectedJSON, data)
+	}
+}
+
+func TestProcessEventsSplitCompetitorTime(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, StartDelta: "00:01:30"}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:50:00.000] 2 1 10:00:00.000",
+		"[09:50:01.000] 2 2 10:01:30.000",
+		"[10:00:00.000] 4 1",
+		"[10:01:30.000] 4 2",
+		"[10:04:05.000] 13 1 1 10:04:00.000",
+		"[10:05:00.000] 13 2 1 [10:04:30.500]",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	at := onDate(time.Date(0, 1, 1, 10, 4, 0, 0, time.UTC), events[6].Time)
+	expected := map[int][]SplitTime{
+		1: {{CheckpointID: 1, Lap: 1, At: at, Elapsed: 4 * time.Minute}},
+		2: {{CheckpointID: 1, Lap: 1, At: at.Add(30*time.Second + 500*time.Millisecond), Elapsed: 3*time.Minute + 500*time.Millisecond}},
+	}
+	for id, splits := range expected {
+		if !reflect.DeepEqual(competitors[id].Splits, splits) {
+			t.Errorf("Competitor %d: expected splits %+v, got %+v", id, splits, competitors[id].Splits)
+		}
+	}
+
+	for _, params := range []string{"1 10:99:00.000", "1 10:04:00.000 2", "x 10:04:00.000"} {
+		events := parseEvents(t, []string{
+			"[09:30:00.000] 1 1",
+			"[09:50:00.000] 2 1 10:00:00.000",
+			"[10:00:00.000] 4 1",
+			"[10:04:05.000] 13 1 " + params,
+		})
+		if _, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict)); err == nil {
+			t.Errorf("Expected an error for checkpoint %q", params)
+		}
+	}
+}
+
+func TestProcessEventsTargetsPerLine(t *testing.T) {
+	lines := []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:01.000] 6 1 1",
+		"[10:05:02.000] 6 1 2",
+		"[10:05:03.000] 6 1 3",
+		"[10:05:04.000] 6 1 4",
+		"[10:05:05.000] 7 1",
+		"[10:12:00.000] 10 1",
+	}
+
+	tests := []struct {
+		name           string
+		targetsPerLine int
+		expected       string
+		warnings       int
+	}{
+		{"default", 0, " 4/5 (R1 4/5) +00:00.000\n", 0},
+		{"three targets", 3, " 4/4 (R1 4/4) +00:00.000\n", 1},
+	}
+
+	for _, test := range tests {
+		config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, TargetsPerLine: test.targetsPerLine}
+
+		p := NewProcessor(config)
+		for _, event := range parseEvents(t, lines) {
+			if err := p.AddEvent(event); err != nil {
+				t.Fatalf("%s: unexpected error: %v", test.name, err)
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := WriteReport(&buf, p.Finalize(), config, FormatText); err != nil {
+			t.Fatalf("%s: unexpected error writing report: %v", test.name, err)
+		}
+
+		if !strings.HasSuffix(buf.String(), test.expected) {
+			t.Errorf("%s: expected report line ending in %q, got:\n%s", test.name, test.expected, buf.String())
+		}
+
+		if len(p.Warnings()) != test.warnings {
+			t.Errorf("%s: expected %d warnings, got %v", test.name, test.warnings, p.Warnings())
+		}
+	}
+}
+
+func TestProcessEventsPartialScore(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	// Two misses owe two penalty loops, which the report must not show as a
+	// perfect score
+	competitors, _, warnings, err := ProcessEvents(context.Background(), parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:01.000] 6 1 1",
+		"[10:05:02.000] 6 1 2",
+		"[10:05:03.000] 6 1 3",
+		"[10:05:04.000] 7 1",
+		"[10:05:10.000] 8 1",
+		"[10:06:50.000] 9 1",
+		"[10:12:00.000] 10 1",
+	}), config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected the penalty loops to match the misses, got %v", warnings)
+	}
+
+	competitor := competitors[1]
+	if competitor.Hits != 3 || competitor.Shots != 5 {
+		t.Errorf("Expected 3 hits out of 5 shots, got %d/%d", competitor.Hits, competitor.Shots)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	if !strings.Contains(buf.String(), " 3/5 (R1 3/5) ") {
+		t.Errorf("Expected a 3/5 score in the report, got:\n%s", buf.String())
+	}
+}
+
+func TestProcessEventsPenaltyLoops(t *testing.T) {
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:01.000] 6 1 1",
+		"[10:05:02.000] 6 1 2",
+		"[10:05:03.000] 6 1 3",
+		"[10:05:04.000] 7 1",
+		"[10:05:10.000] 8 1",
+		"[10:06:50.000] 9 1",
+		"[10:12:00.000] 10 1",
+	})
+
+	tests := []struct {
+		name          string
+		loopsPerMiss  int
+		expectedLoops int
+	}{
+		{"default", 0, 2},
+		{"two loops per miss", 2, 4},
+	}
+
+	for _, test := range tests {
+		config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, PenaltyLoopsPerMiss: test.loopsPerMiss}
+
+		competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+
+		expected := []RangeVisit{{
+			Lap:                  1,
+			FiringRange:          1,
+			Enter:                events[2].Time,
+			Leave:                events[6].Time,
+			Hits:                 3,
+			Misses:               2,
+			ExpectedPenaltyLoops: test.expectedLoops,
+			PenaltyTime:          100 * time.Second,
+			PenaltyLen:           150,
+		}}
+		if !reflect.DeepEqual(competitors[1].RangeVisits, expected) {
+			t.Errorf("%s: expected range visits %+v, got %+v", test.name, expected, competitors[1].RangeVisits)
+		}
+
+		penaltyStats := competitors[1].TotalPenaltyStats(config)
+		expectedSpeed := float64(150*test.expectedLoops) / 100
+		if penaltyStats.Speed != expectedSpeed {
+			t.Errorf("%s: expected penalty speed %.3f, got %.3f", test.name, expectedSpeed, penaltyStats.Speed)
+		}
+	}
+}
+
+func TestProcessEventsFiringLines(t *testing.T) {
+	config := Configuration{Laps: 3, LapLen: 3500, PenaltyLen: 150, FiringLines: 2}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 5 1 3",
+		"[10:12:00.000] 10 1",
+		"[10:17:00.000] 5 1 1",
+		"[10:17:05.000] 7 1",
+		"[10:18:00.000] 5 1 2",
+		"[10:18:05.000] 7 1",
+		"[10:24:00.000] 10 1",
+		"[10:30:00.000] 5 1 1",
+		"[10:30:05.000] 7 1",
+		"[10:36:00.000] 10 1",
+	})
+
+	p := NewProcessor(config)
+	var errs []error
+	for _, event := range events {
+		if err := p.AddEvent(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "firing range 3 does not exist, the course has 2") {
+		t.Errorf("Expected the visit to range 3 to be rejected, got %v", errs)
+	}
+
+	expected := []string{
+		"[10:12:00.000] event 10 for competitor(1): competitor(1) completed lap 1 without visiting a firing range",
+		"[10:24:00.000] event 10 for competitor(1): competitor(1) visited 2 firing ranges on lap 2",
+		"[10:30:00.000] event 5 for competitor(1): competitor(1) visited more than 2 firing ranges",
+	}
+
+	warnings := p.Warnings()
+	if len(warnings) != len(expected) {
+		t.Fatalf("Expected %d warnings, got %v", len(expected), warnings)
+	}
+
+	for i, warning := range warnings {
+		if warning.String() != expected[i] {
+			t.Errorf("Warning %d: expected %q, got %q", i, expected[i], warning.String())
+		}
+	}
+
+	visits := p.Results()[1].RangeVisits
+	if len(visits) != 3 || visits[0].Lap != 2 || visits[1].FiringRange != 2 || visits[2].Lap != 3 {
+		t.Errorf("Unexpected range visits: %+v", visits)
+	}
+}
+
+func TestProcessEventsDerivedStartTimes(t *testing.T) {
+	config := Configuration{
+		Laps:       1,
+		LapLen:     3500,
+		PenaltyLen: 150,
+		Start:      "10:00:00.000",
+		StartDelta: "00:01:30",
+	}
+
+	events := parseEvents(t, []string{
+		"[09:00:00.000] 1 7",
+		"[09:01:00.000] 1 3",
+		"[09:02:00.000] 1 5",
+		"[09:15:00.000] 2 3 10:10:00.000",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[int]string{
+		7: "10:00:00.000", // first registered starts at Start
+		3: "10:10:00.000", // explicit draw wins over the derived time
+		5: "10:03:00.000", // third registered starts two StartDeltas later
+	}
+	for id, want := range expected {
+		if got := formatTime(competitors[id].PlannedStartTime); got != want {
+			t.Errorf("Expected competitor %d planned start %s, got %s", id, want, got)
+		}
+	}
+}
+
+func TestProcessEventsIgnoredAfterDisqualification(t *testing.T) {
+	config := Configuration{
+		Laps:       2,
+		LapLen:     3500,
+		PenaltyLen: 150,
+		Start:      "10:00:00.000",
+		StartDelta: "00:00:30",
+	}
+
+	events := parseEvents(t, []string{
+		"[09:00:00.000] 1 1",
+		"[09:50:00.000] 2 1 10:00:00.000",
+		"[10:05:00.000] 4 1",
+		"[10:10:00.000] 5 1 1",
+		"[10:10:05.000] 6 1 1",
+		"[10:10:30.000] 7 1",
+		"[10:11:00.000] 8 1",
+		"[10:12:00.000] 9 1",
+		"[10:15:00.000] 10 1",
+		"[10:25:00.000] 10 1",
+	})
+
+	var out, outgoing bytes.Buffer
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict),
+		WithLogger(narrationLogger(&out)), WithOutgoing(&outgoing))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	competitor := competitors[1]
+	if competitor.Status != "Disqualified" {
+		t.Errorf("Expected Disqualified, got %s", competitor.Status)
+	}
+	if len(competitor.LapTimes) != 0 || competitor.Hits != 0 || competitor.Shots != 0 || competitor.TotalPenaltyTime != 0 {
+		t.Errorf("Expected no race progress after disqualification, got %+v", competitor)
+	}
+	if outgoing.String() != "[10:05:00.000] 32 1\n" {
+		t.Errorf("Expected only the disqualification event, got %q", outgoing.String())
+	}
+	if !strings.Contains(out.String(), "[10:25:00.000] The competitor(1) is disqualified, event 10 ignored\n") {
+		t.Errorf("Expected the ignored lap to be noted, got:\n%s", out.String())
+	}
+
+	var report bytes.Buffer
+	if err := WriteReport(&report, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing text report: %v", err)
+	}
+	expected := "\nFinal Results:\n" +
+		"[Disqualified (started outside allowed window: planned 10:00:00.000, actual 10:05:00.000)] 1 [{,}, {,}] {,} 0/0 NT\n"
+	if report.String() != expected {
+		t.Errorf("Expected report %q, got %q", expected, report.String())
+	}
+}
+
+func TestProcessEventsMissedTargets(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, FiringLines: 1}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:01.000] 6 1 1",
+		"[10:05:02.000] 15 1 2",
+		"[10:05:03.000] 6 1 3",
+		"[10:05:04.000] 15 1 4",
+		"[10:05:05.000] 7 1",
+		"[10:12:00.000] 10 1",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config,
+		WithMode(Strict), WithStateValidation(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Target 5 was never fired at and counts as a miss when leaving the range
+	competitor := competitors[1]
+	if competitor.Hits != 2 || competitor.Shots != 5 || competitor.PerRangeShots[1] != 5 {
+		t.Errorf("Expected 2 hits of 5 shots at range 1, got %d/%d %v",
+			competitor.Hits, competitor.Shots, competitor.PerRangeShots)
+	}
+	if visit := competitor.RangeVisits[0]; visit.Misses != 3 || visit.ExpectedPenaltyLoops != 3 {
+		t.Errorf("Expected 3 misses owing 3 penalty loops, got %+v", visit)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), " 2/5 (R1 2/5) +00:00.000\n") {
+		t.Errorf("Expected 2/5 in the report, got:\n%s", buf.String())
+	}
+}
+
+func TestProcessEventsExtraLaps(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:12:00.000] 10 1",
+		"[10:24:00.000] 10 1",
+		"[10:36:00.000] 10 1",
+		"[10:48:00.000] 10 1",
+	})
+
+	var lapEvents int
+	p := NewProcessor(config)
+	p.OnEvent(10, func(EventLog, *Competitor) { lapEvents++ })
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	competitor := p.Finalize()[1]
+	if lapEvents != 2 {
+		t.Errorf("Expected the hooks to see only the 2 laps, got %d event 10s", lapEvents)
+	}
+
+	expectedLaps := []time.Duration{12 * time.Minute, 12 * time.Minute}
+	if !reflect.DeepEqual(competitor.LapTimes, expectedLaps) || competitor.CurrentLap != 3 {
+		t.Errorf("Expected lap times %v ending on lap 3, got %v on lap %d", expectedLaps, competitor.LapTimes, competitor.CurrentLap)
+	}
+	if competitor.Status != "Finished" || !competitor.FinishTime.Equal(events[3].Time) {
+		t.Errorf("Expected to finish at %s, got %s at %s", formatTime(events[3].Time), competitor.Status, formatTime(competitor.FinishTime))
+	}
+
+	warnings := p.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("Expected a warning for each extra lap, got %v", warnings)
+	}
+	expected := "[10:48:00.000] event 10 for competitor(1): competitor(1) already completed all 2 laps, event 10 ignored"
+	if warnings[1].String() != expected {
+		t.Errorf("Expected warning %q, got %q", expected, warnings[1].String())
+	}
+}
+
+func TestProcessEventsStartGun(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, Start: "10:00:00.000", StartDelta: "00:00:30", MassStart: true}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:30:02.000] 1 3",
+		"[09:55:00.000] 3 2",
+		"[09:56:00.000] 11 3 Ill",
+		"[10:02:00.000] 17",
+		"[10:14:00.000] 10 1",
+		"[10:14:30.000] 10 2",
+		"[10:15:00.000] 14 1 17 10:01:50.000 Gun timing",
+	})
+
+	var started []int
+	p := NewProcessor(config, WithStateValidation(true))
+	p.OnEvent(17, func(event EventLog, competitor *Competitor) {
+		started = append(started, competitor.ID)
+	})
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	competitors := p.Finalize()
+
+	if !reflect.DeepEqual(started, []int{1, 2}) {
+		t.Errorf("Expected the gun to start competitors [1 2], got %v", started)
+	}
+	for _, id := range []int{1, 2} {
+		if competitors[id].Status != "Finished" {
+			t.Errorf("Expected competitor %d to finish after a late gun, got %s", id, competitors[id].Status)
+		}
+	}
+	if !competitors[2].ActualStartTime.Equal(events[5].Time) {
+		t.Errorf("Expected competitor 2 to start at the gun, got %s", formatTime(competitors[2].ActualStartTime))
+	}
+	if competitors[3].Status != "NotFinished" || !competitors[3].ActualStartTime.IsZero() {
+		t.Errorf("Expected competitor 3 not to be started by the gun, got %s", competitors[3].Status)
+	}
+
+	// The corrected gun time is the start of competitor 1 only
+	if lap := competitors[1].LapTimes[0]; lap != 12*time.Minute+10*time.Second {
+		t.Errorf("Expected the corrected lap to take 12:10, got %v", lap)
+	}
+	if lap := competitors[2].LapTimes[0]; lap != 12*time.Minute+30*time.Second {
+		t.Errorf("Expected competitor 2 to lap in 12:30, got %v", lap)
+	}
+
+	gunForOne := parseEvents(t, []string{"[09:30:00.000] 1 1", "[10:00:00.000] 17 1"})
+	if err := NewProcessor(config).AddEvents(context.Background(), gunForOne); err == nil {
+		t.Error("Expected an error for a start gun for one competitor")
+	}
+}
+
+func TestProcessEventsIgnoresOutgoingEvents(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, Start: "10:00:00.000", StartDelta: "00:01:30"}
+
+	// The outgoing events of an earlier run, appended after the incoming ones
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:10.000] 4 1",
+		"[10:12:10.000] 10 1",
+		"[10:12:10.000] 33 1",
+		"[09:00:00.000] 32 2",
+	})
+
+	p := NewProcessor(config, WithStrictOrdering(true))
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	competitors := p.Finalize()
+
+	if len(competitors) != 1 || competitors[1].Status != "Finished" {
+		t.Errorf("Expected competitor 1 alone to finish, got %v", competitors)
+	}
+	expected := []string{
+		"[10:12:10.000] event 33 for competitor(1): outgoing event 33 in the input is ignored",
+		"[09:00:00.000] event 32 for competitor(2): outgoing event 32 in the input is ignored",
+	}
+	warnings := p.Warnings()
+	if len(warnings) != len(expected) {
+		t.Fatalf("Expected %d warnings, got %v", len(expected), warnings)
+	}
+	for i, warning := range warnings {
+		if warning.String() != expected[i] {
+			t.Errorf("Warning %d: expected %q, got %q", i, expected[i], warning.String())
+		}
+	}
+	if outgoing := p.OutgoingEvents(); len(outgoing) != 1 || outgoing[0].EventID != EventFinished {
+		t.Errorf("Expected the finish to be emitted once, got %v", outgoing)
+	}
+}
+
+func TestProcessEventsMassStart(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, Start: "10:00:00.000", StartDelta: "00:00:30", MassStart: true}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:30:02.000] 1 3",
+		"[09:45:00.000] 2 2 10:01:00.000",
+		"[10:00:00.000] 4 1",
+		"[10:00:20.000] 4 2",
+		"[10:00:40.000] 4 3",
+		"[10:12:10.000] 10 2",
+		"[10:12:15.000] 10 1",
+	})
+
+	p := NewProcessor(config)
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	competitors := p.Finalize()
+
+	start := events[4].Time
+	for id, competitor := range competitors {
+		if !competitor.PlannedStartTime.Equal(start) {
+			t.Errorf("Expected competitor %d to start with the mass start, got %s", id, formatTime(competitor.PlannedStartTime))
+		}
+	}
+
+	expected := "[09:45:00.000] event 2 for competitor(2): competitor(2) starts with the mass start at 10:00:00.000, ignoring the drawn start time 10:01:00.000"
+	if warnings := p.Warnings(); len(warnings) != 1 || warnings[0].String() != expected {
+		t.Errorf("Expected a warning about the drawn start time, got %v", warnings)
+	}
+
+	// Competitor 3 started after the 30 seconds allowed
+	if competitors[3].Status != "Disqualified" {
+		t.Errorf("Expected competitor 3 to be disqualified, got %s", competitors[3].Status)
+	}
+
+	// Times are taken from the mass start, so competitor 2 is ahead despite
+	// spending longer on the course
+	rows := BuildResults(competitors, config)
+	if rows[0].CompetitorID != 2 || rows[0].TotalTime != 12*time.Minute+10*time.Second || rows[1].CompetitorID != 1 {
+		t.Errorf("Expected competitor 2 to win from the mass start, got %+v", rows[:2])
+	}
+}
+
+func TestProcessEventsShootingBouts(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150, FiringLines: 2}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:01.000] 6 1 1",
+		"[10:05:02.000] 6 1 2",
+		"[10:05:03.000] 6 1 3",
+		"[10:05:04.000] 6 1 4",
+		"[10:05:30.000] 7 1",
+		"[10:12:00.000] 10 1",
+		"[10:17:00.000] 5 1 2",
+		"[10:17:45.000] 7 1",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The second bout had no hits at all and still counts as a full bout
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	if !strings.Contains(buf.String(), " 4/10 (R1 4/5, R2 0/5) ") {
+		t.Errorf("Expected the per-bout breakdown in the report, got:\n%s", buf.String())
+	}
+
+	visits := BuildReportEntries(competitors, config)[0].RangeVisits
+	expected := []RangeVisitEntry{
+		{Lap: 1, FiringRange: 1, Enter: "10:05:00.000", Leave: "10:05:30.000", Duration: "00:00:30.000", Hits: 4, Misses: 1, Shots: 5},
+		{Lap: 2, FiringRange: 2, Enter: "10:17:00.000", Leave: "10:17:45.000", Duration: "00:00:45.000", Hits: 0, Misses: 5, Shots: 5},
+	}
+	if !reflect.DeepEqual(visits, expected) {
+		t.Errorf("Expected range visits %+v, got %+v", expected, visits)
+	}
+}
+
+func TestProcessEventsLapDebounce(t *testing.T) {
+	lines := []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:10:00.000] 10 1",
+		"[10:10:00.120] 10 1",
+		"[10:21:00.000] 10 1",
+	}
+
+	t.Run("debounced", func(t *testing.T) {
+		config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150, LapDebounceMillis: 500}
+
+		// The duplicate must not count as a lap for the state validation,
+		// or the real last lap would be rejected
+		var lapEvents int
+		p := NewProcessor(config, WithStateValidation(true))
+		p.OnEvent(10, func(EventLog, *Competitor) { lapEvents++ })
+		for _, event := range parseEvents(t, lines) {
+			if err := p.AddEvent(event); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+
+		competitor := p.Finalize()[1]
+		expected := []time.Duration{10 * time.Minute, 11 * time.Minute}
+		if competitor.Status != "Finished" || !reflect.DeepEqual(competitor.LapTimes, expected) {
+			t.Errorf("Expected Finished with laps %v, got %s %v", expected, competitor.Status, competitor.LapTimes)
+		}
+
+		warnings := p.Warnings()
+		if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "ignored as a duplicate") {
+			t.Errorf("Expected one duplicate warning, got %v", warnings)
+		}
+		if lapEvents != 2 {
+			t.Errorf("Expected the hooks to see 2 event 10s, got %d", lapEvents)
+		}
+	})
+
+	t.Run("without debounce", func(t *testing.T) {
+		config := Configuration{Laps: 3, LapLen: 3500, PenaltyLen: 150}
+
+		p := NewProcessor(config)
+		for _, event := range parseEvents(t, []string{
+			"[09:30:00.000] 1 1",
+			"[10:00:00.000] 4 1",
+			"[10:10:00.000] 10 1",
+			"[10:10:00.000] 10 1",
+		}) {
+			if err := p.AddEvent(event); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+
+		// A zero-length lap would report an infinite speed
+		if laps := len(p.Results()[1].LapTimes); laps != 1 {
+			t.Errorf("Expected 1 lap, got %d", laps)
+		}
+		warnings := p.Warnings()
+		if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "ignored as a duplicate event 10") {
+			t.Errorf("Expected one duplicate warning, got %v", warnings)
+		}
+
+		var out bytes.Buffer
+		if err := WriteReport(&out, p.Finalize(), config, FormatJSON); err != nil {
+			t.Fatalf("Unexpected error writing the JSON report: %v", err)
+		}
+		if strings.Contains(out.String(), "Inf") {
+			t.Errorf("Expected finite speeds, got %s", out.String())
+		}
+	})
+}
+
+func TestProcessorReset(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, FiringLines: 1, Start: "10:00:00.000", StartDelta: "00:00:30"}
+
+	p := NewProcessor(config)
+	for _, event := range parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:12:00.000] 10 1",
+	}) {
+		if err := p.AddEvent(event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	p.Finalize()
+
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"laps": 0, "lapLen": 3500, "start": "11:00:00.000"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reset(invalid); err == nil {
+		t.Fatal("Expected an error for an invalid configuration")
+	}
+	if len(p.Results()) != 1 || p.Config().Laps != 1 {
+		t.Fatalf("Expected the processor to be unchanged after a failed reset")
+	}
+
+	next := filepath.Join(dir, "next.json")
+	if err := os.WriteFile(next, []byte(`{"laps": 2, "lapLen": 3000, "start": "11:00:00.000"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reset(next); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(p.Results()) != 0 || len(p.OutgoingEvents()) != 0 || len(p.Warnings()) != 0 {
+		t.Errorf("Expected a clean processor, got %v %v %v", p.Results(), p.OutgoingEvents(), p.Warnings())
+	}
+	if p.Config().Laps != 2 || p.Config().Start != "11:00:00.000" {
+		t.Errorf("Expected the new configuration, got %+v", p.Config())
+	}
+
+	// Registration starts over, so the first competitor gets the new Start
+	if err := p.AddEvent(parseEvents(t, []string{"[10:30:00.000] 1 7"})[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := formatTime(p.Results()[7].PlannedStartTime); got != "11:00:00.000" {
+		t.Errorf("Expected planned start 11:00:00.000, got %s", got)
+	}
+}
+
+func TestProcessEventsAcrossMidnight(t *testing.T) {
+	config := Configuration{
+		Laps:        2,
+		LapLen:      3000,
+		PenaltyLen:  150,
+		FiringLines: 1,
+		Start:       "23:50:00.000",
+		StartDelta:  "00:00:30",
+	}
+
+	events := parseEvents(t, []string{
+		"[23:30:00.000] 1 1",
+		"[23:31:00.000] 1 2",
+		"[23:40:00.000] 2 2 00:01:00.000",
+		"[23:50:00.000] 4 1",
+		"[23:59:00.000] 5 1 1",
+		"[23:59:10.000] 6 1 1",
+		"[23:59:20.000] 7 1",
+		"[23:59:40.000] 8 1",
+		"[00:00:40.000] 9 1",
+		"[00:01:00.000] 4 2",
+		"[00:05:00.000] 10 1",
+		"[00:20:00.000] 10 1",
+		"[00:21:00.000] 10 2",
+		"[00:40:00.000] 10 2",
+	})
+
+	competitors, outgoing, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	first := competitors[1]
+	if !reflect.DeepEqual(first.LapTimes, []time.Duration{15 * time.Minute, 15 * time.Minute}) {
+		t.Errorf("Expected two 15 minute laps, got %v", first.LapTimes)
+	}
+	if first.TotalPenaltyTime != time.Minute {
+		t.Errorf("Expected one minute of penalty laps, got %s", first.TotalPenaltyTime)
+	}
+	if len(outgoing) != 2 || formatTime(outgoing[0].Time) != "00:20:00.000" {
+		t.Errorf("Expected both competitors to finish, got %v", outgoing)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	expected := "\nFinal Results:\n" +
+		"1. [00:30:00.000] 1 [{00:15:00.000, 3.333}, {00:15:00.000, 3.333}] {00:01:00.000, 10.000} 1/5 (R1 1/5) +00:00.000\n" +
+		"2. [00:39:00.000] 2 [{00:20:00.000, 2.500}, {00:19:00.000, 2.632}] {,} 0/0 +09:00.000 (negative split on laps 2)\n"
+	if buf.String() != expected {
+		t.Errorf("Expected report:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestProcessEventsWithRaceDate(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3000, Start: "23:50:00.000", StartDelta: "00:00:30"}
+
+	events := parseEvents(t, []string{
+		"[21:45:00.000] 1 1",
+		"[22:00:00.000] 1 2",
+		"[23:50:00.000] 4 1",
+		"[23:50:30.000] 4 2",
+		"[00:10:00.000] 10 1",
+		"[02:30:00.000] 10 2",
+	})
+
+	raceDate := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict), WithRaceDate(raceDate))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	first := competitors[1]
+	if !first.RegisteredTime.Equal(time.Date(2025, time.March, 1, 21, 45, 0, 0, time.UTC)) {
+		t.Errorf("Expected registration on the race date, got %s", first.RegisteredTime)
+	}
+	if !first.FinishTime.Equal(time.Date(2025, time.March, 2, 0, 10, 0, 0, time.UTC)) {
+		t.Errorf("Expected the finish after midnight on the next day, got %s", first.FinishTime)
+	}
+	if first.TotalRaceTime() != 20*time.Minute {
+		t.Errorf("Expected a 20 minute race, got %s", first.TotalRaceTime())
+	}
+
+	second := competitors[2]
+	if !second.FinishTime.Equal(time.Date(2025, time.March, 2, 2, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected the finish at 02:30 on the next day, got %s", second.FinishTime)
+	}
+}
+
+func TestProcessEventsFrozenAfterCantContinue(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:12:00.000] 10 1",
+		"[10:15:00.000] 11 1 Broken ski",
+		"[10:16:00.000] 8 1",
+		"[10:17:00.000] 9 1",
+		"[10:25:00.000] 10 1",
+	})
+
+	var notified []int
+	p := NewProcessor(config, WithMode(Strict))
+	p.OnAnyEvent(func(event EventLog, _ *Competitor) { notified = append(notified, event.EventID) })
+	for _, event := range events {
+		if err := p.AddEvent(event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	competitor := p.Finalize()[1]
+	if !reflect.DeepEqual(notified, []int{1, 4, 10, 11}) {
+		t.Errorf("Expected the hooks not to see the ignored events, got %v", notified)
+	}
+	if competitor.Status != "NotFinished" || competitor.DNFReason != "Broken ski" {
+		t.Errorf("Expected NotFinished (Broken ski), got %s (%s)", competitor.Status, competitor.DNFReason)
+	}
+	if !reflect.DeepEqual(competitor.LapTimes, []time.Duration{12 * time.Minute}) || competitor.TotalPenaltyTime != 0 {
+		t.Errorf("Expected only the lap before event 11, got laps %v and penalty %s",
+			competitor.LapTimes, competitor.TotalPenaltyTime)
+	}
+	if len(p.OutgoingEvents()) != 0 {
+		t.Errorf("Expected no finish event, got %v", p.OutgoingEvents())
+	}
+	if warnings := p.Warnings(); len(warnings) != 3 {
+		t.Errorf("Expected the three ignored events as warnings, got %v", warnings)
+	}
+}
+
+func TestProcessEventsSkippedPenaltyLoops(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150}
+
+	// Lap 1 at 5 m/s makes a penalty loop about 30 seconds; three misses owe
+	// about 90 seconds of penalty laps
+	lines := func(penaltyEnd string) []string {
+		return []string{
+			"[09:30:00.000] 1 1",
+			"[10:00:00.000] 4 1",
+			"[10:11:40.000] 10 1",
+			"[10:15:00.000] 5 1 1",
+			"[10:15:01.000] 6 1 1",
+			"[10:15:02.000] 6 1 2",
+			"[10:15:10.000] 7 1",
+			"[10:15:20.000] 8 1",
+			"[" + penaltyEnd + "] 9 1",
+			"[10:25:00.000] 10 1",
+		}
+	}
+
+	tests := []struct {
+		name       string
+		penaltyEnd string
+		warnings   []string
+	}{
+		{"full penalty", "10:16:50.000", nil},
+		{"slow penalty", "10:17:20.000", nil},
+		{"skipped loops", "10:15:50.000", []string{"competitor(1) ran about 1.0 of the 3 penalty loops owed for firing range 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProcessor(config)
+			for _, event := range parseEvents(t, lines(tt.penaltyEnd)) {
+				if err := p.AddEvent(event); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			var warnings []string
+			for _, warning := range p.Warnings() {
+				warnings = append(warnings, warning.Message)
+			}
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("Expected warnings %q, got %q", tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestProcessEventsUnreconciledPenalties(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	start := []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:01.000] 6 1 1",
+		"[10:05:10.000] 7 1",
+	}
+
+	tests := []struct {
+		name      string
+		events    []string
+		penalty   time.Duration
+		anomalies []string
+		report    string
+	}{
+		{
+			name:    "matched pair",
+			events:  []string{"[10:05:20.000] 8 1", "[10:07:20.000] 9 1", "[10:12:00.000] 10 1"},
+			penalty: 2 * time.Minute,
+			report:  " 1/5 (R1 1/5) +00:00.000\n",
+		},
+		{
+			name:    "orphan 9",
+			events:  []string{"[10:07:20.000] 9 1", "[10:12:00.000] 10 1"},
+			penalty: 2*time.Minute + 10*time.Second,
+			anomalies: []string{
+				"left the penalty laps at 10:07:20.000 without entering them, penalty time estimated from leaving firing range 1 at 10:05:10.000",
+			},
+			report: " 1/5 (R1 1/5) +00:00.000 (needs review)\n\nNeeds review:\n" +
+				"1: left the penalty laps at 10:07:20.000 without entering them, penalty time estimated from leaving firing range 1 at 10:05:10.000\n",
+		},
+		{
+			name:   "orphan 8 open at finish",
+			events: []string{"[10:05:20.000] 8 1", "[10:12:00.000] 10 1"},
+			anomalies: []string{
+				"finished without leaving the penalty laps entered at 10:05:20.000, penalty time unknown",
+			},
+			report: " 1/5 (R1 1/5) +00:00.000 (needs review)\n\nNeeds review:\n" +
+				"1: finished without leaving the penalty laps entered at 10:05:20.000, penalty time unknown\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			competitors, _, _, err := ProcessEvents(context.Background(), parseEvents(t, append(start, tt.events...)),
+				config, WithMode(Strict))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			competitor := competitors[1]
+			if competitor.TotalPenaltyTime != tt.penalty {
+				t.Errorf("Expected penalty time %s, got %s", tt.penalty, competitor.TotalPenaltyTime)
+			}
+			if !reflect.DeepEqual(competitor.Anomalies, tt.anomalies) {
+				t.Errorf("Expected anomalies %q, got %q", tt.anomalies, competitor.Anomalies)
+			}
+
+			var buf bytes.Buffer
+			if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+				t.Fatalf("Unexpected error writing report: %v", err)
+			}
+			if !strings.HasSuffix(buf.String(), tt.report) {
+				t.Errorf("Expected report ending in %q, got %q", tt.report, buf.String())
+			}
+		})
+	}
+
+	// Without a range visit to estimate from, the penalty time stays unknown
+	competitors, _, _, err := ProcessEvents(context.Background(), parseEvents(t, []string{
+		"[09:30:00.000] 1 2",
+		"[10:00:00.000] 4 2",
+		"[10:07:20.000] 9 2",
+	}), config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if competitor := competitors[2]; competitor.TotalPenaltyTime != 0 || len(competitor.Anomalies) != 1 {
+		t.Errorf("Expected an anomaly without penalty time, got %s %q", competitor.TotalPenaltyTime, competitor.Anomalies)
+	}
+}
+
+func TestProcessEventsWithdrew(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150, StartDelta: "00:01:30"}
+
+	// Competitor 4 never starts and is disqualified once their start window
+	// has passed
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:30:02.000] 1 3",
+		"[09:30:03.000] 1 4",
+		"[09:50:00.000] 2 4 10:00:00.000",
+		"[10:00:00.000] 4 1",
+		"[10:00:01.000] 4 2",
+		"[10:00:02.000] 4 3",
+		"[10:10:00.000] 11 1 WD: illness",
+		"[10:11:00.000] 11 2 withdrew after the first loop",
+		"[10:12:00.000] 11 3 Broken ski",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[int]string{1: "Withdrew", 2: "Withdrew", 3: "NotFinished", 4: "Disqualified"}
+	for id, status := range expected {
+		if competitors[id].Status != status {
+			t.Errorf("Expected competitor %d %s, got %s", id, status, competitors[id].Status)
+		}
+	}
+
+	entries := BuildReportEntries(competitors, config)
+	var order []int
+	for _, entry := range entries {
+		order = append(order, entry.CompetitorID)
+	}
+	if !reflect.DeepEqual(order, []int{3, 1, 2, 4}) {
+		t.Errorf("Expected NotFinished before Withdrew before Disqualified, got %v", order)
+	}
+
+	data, err := json.Marshal(entries[1])
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling entry: %v", err)
+	}
+	if !strings.Contains(string(data), `"status":"Withdrew","dnfReason":"WD: illness"`) {
+		t.Errorf("Expected the status and DNF reason separately, got %s", data)
+	}
+}
+
+func TestProcessEventsTotalTimeFromPlannedStart(t *testing.T) {
+	config := Configuration{
+		Laps:       1,
+		LapLen:     3500,
+		PenaltyLen: 150,
+		Start:      "10:00:00.000",
+		StartDelta: "00:00:30",
+	}
+
+	// Both ski the course in exactly 12 minutes; competitor 2 starts 3 seconds late
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:40:00.000] 2 1 10:00:00.000",
+		"[09:40:01.000] 2 2 10:01:00.000",
+		"[10:00:00.000] 4 1",
+		"[10:01:03.000] 4 2",
+		"[10:12:00.000] 10 1",
+		"[10:13:03.000] 10 2",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	expected := "\nFinal Results:\n" +
+		"1. [00:12:00.000] 1 [{00:12:00.000, 4.861}] {,} 0/0 +00:00.000\n" +
+		"2. [00:12:03.000] 2 [{00:12:00.000, 4.861}] {,} 0/0 +00:03.000\n"
+	if buf.String() != expected {
+		t.Errorf("Expected report:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
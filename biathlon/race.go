@@ -0,0 +1,154 @@
+package biathlon
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DistanceRevision is a re-measurement of the course (event 16). Laps and
+// penalty laps started before At keep the previous distances.
+type DistanceRevision struct {
+	At                 time.Time
+	LapLen             int
+	PenaltyLen         int
+	PreviousLapLen     int
+	PreviousPenaltyLen int
+	previousLapLens    []int
+}
+
+// RaceState is the state of the race as a whole rather than of a competitor.
+type RaceState struct {
+	DistanceRevisions []DistanceRevision // oldest first
+	FiringRangeQueue  []int              // IDs of the competitors on the firing range, in order of arrival
+	RelayTeams        []RelayTeam        // in relay mode, in order of their first baton
+}
+
+// RaceState returns a copy of the race-wide state.
+func (p *Processor) RaceState() RaceState {
+	var teams []RelayTeam
+	for _, team := range p.race.RelayTeams {
+		teams = append(teams, team.clone())
+	}
+
+	return RaceState{
+		DistanceRevisions: slices.Clone(p.race.DistanceRevisions),
+		FiringRangeQueue:  slices.Clone(p.race.FiringRangeQueue),
+		RelayTeams:        teams,
+	}
+}
+
+// remeasure handles event 16 with "lapLen=<int> penaltyLen=<int>"; a distance
+// left out is kept. A new lap length applies to every lap, replacing LapLens.
+func (p *Processor) remeasure(event EventLog) error {
+	revision := DistanceRevision{
+		At:                 event.Time,
+		LapLen:             p.config.LapLen,
+		PenaltyLen:         p.config.PenaltyLen,
+		PreviousLapLen:     p.config.LapLen,
+		PreviousPenaltyLen: p.config.PenaltyLen,
+		previousLapLens:    p.config.LapLens,
+	}
+
+	lapLens := p.config.LapLens
+	params := strings.Fields(event.ExtraParams)
+	if len(params) == 0 {
+		return errors.New("re-measurement without distances")
+	}
+	for _, param := range params {
+		name, value, _ := strings.Cut(param, "=")
+		length, err := strconv.Atoi(value)
+		if err != nil || length <= 0 {
+			return fmt.Errorf("invalid distance %q", param)
+		}
+
+		switch name {
+		case "lapLen":
+			revision.LapLen = length
+			lapLens = nil
+		case "penaltyLen":
+			revision.PenaltyLen = length
+		default:
+			return fmt.Errorf("unknown distance %q", name)
+		}
+	}
+
+	p.config.LapLen = revision.LapLen
+	p.config.LapLens = lapLens
+	p.config.PenaltyLen = revision.PenaltyLen
+	p.race.DistanceRevisions = append(p.race.DistanceRevisions, revision)
+	p.logf(slog.LevelWarn, event, "The course was re-measured: laps are %d m, penalty loops %d m",
+		revision.LapLen, revision.PenaltyLen)
+
+	return nil
+}
+
+// fireStartGun handles event 17, which starts every registered competitor
+// who has not started yet. Each of them gets their own event 17, so it is in
+// their history and the hooks see it like any event of theirs.
+func (p *Processor) fireStartGun(event EventLog) error {
+	if event.CompetitorID != 0 {
+		return errors.New("the start gun is for all competitors, not one")
+	}
+
+	ids := make([]int, 0, len(p.competitors))
+	for id, competitor := range p.competitors {
+		if competitor.Status == "NotStarted" && !competitor.RegisteredTime.IsZero() {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	p.logf(slog.LevelInfo, event, "The start gun was fired for %d competitors", len(ids))
+	for _, id := range ids {
+		gunStart := event
+		gunStart.CompetitorID = id
+		if err := p.applyToCompetitor(gunStart); err != nil {
+			return fmt.Errorf("starting competitor %d: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// courseAt returns the configuration with the course distances in effect at
+// t, undoing the re-measurements made after it.
+func (p *Processor) courseAt(t time.Time) Configuration {
+	config := p.config
+	revisions := p.race.DistanceRevisions
+	for i := len(revisions) - 1; i >= 0 && revisions[i].At.After(t); i-- {
+		config.LapLen = revisions[i].PreviousLapLen
+		config.PenaltyLen = revisions[i].PreviousPenaltyLen
+		config.LapLens = revisions[i].previousLapLens
+	}
+
+	return config
+}
+
+// enterRange puts competitor on the firing range, warning if FiringLines
+// competitors are on it already. A competitor already on it, e.g. after a
+// repeated event 5, keeps their place.
+func (p *Processor) enterRange(competitor *Competitor, event EventLog) {
+	if p.replaying || slices.Contains(p.race.FiringRangeQueue, competitor.ID) {
+		return
+	}
+	if p.config.FiringLines > 0 && len(p.race.FiringRangeQueue) >= p.config.FiringLines {
+		p.warnf(event, "%s entered the firing range with %d competitors on it, range oversubscribed",
+			competitor.Label(), len(p.race.FiringRangeQueue))
+	}
+	p.race.FiringRangeQueue = append(p.race.FiringRangeQueue, competitor.ID)
+}
+
+// leaveRange takes the competitor off the firing range, if they are on it.
+func (p *Processor) leaveRange(competitorID int) {
+	if p.replaying {
+		return
+	}
+	p.race.FiringRangeQueue = slices.DeleteFunc(p.race.FiringRangeQueue, func(id int) bool {
+		return id == competitorID
+	})
+}
@@ -0,0 +1,175 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessEventsRemeasurement(t *testing.T) {
+	config := Configuration{Laps: 2, LapLen: 3000, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:00:00.000] 1 1",
+		"[09:01:00.000] 1 2",
+		"[10:00:00.000] 4 1",
+		"[10:02:00.000] 5 1 1",
+		"[10:03:00.000] 7 1",
+		"[10:03:10.000] 8 1",
+		"[10:04:10.000] 9 1",
+		"[10:05:00.000] 16 0 lapLen=3300 penaltyLen=165",
+		"[10:10:00.000] 4 2",
+		"[10:12:00.000] 5 2 1",
+		"[10:13:00.000] 7 2",
+		"[10:13:10.000] 8 2",
+		"[10:14:10.000] 9 2",
+		"[10:15:00.000] 10 1",
+		"[10:25:00.000] 10 2",
+		"[10:30:00.000] 10 1",
+		"[10:40:00.000] 10 2",
+	})
+
+	p := NewProcessor(config, WithMode(Strict))
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	competitors := p.Finalize()
+
+	if config := p.Config(); config.LapLen != 3300 || config.PenaltyLen != 165 {
+		t.Errorf("Expected the configuration to be re-measured, got lapLen %d, penaltyLen %d", config.LapLen, config.PenaltyLen)
+	}
+
+	// Competitor 1's first lap and penalty loops started before the
+	// re-measurement and keep the old distances
+	tests := []struct {
+		competitorID int
+		lapSpeeds    []float64
+		penaltySpeed float64
+	}{
+		{1, []float64{3000.0 / 900, 3300.0 / 900}, 5 * 150.0 / 60},
+		{2, []float64{3300.0 / 900, 3300.0 / 900}, 5 * 165.0 / 60},
+	}
+	for _, test := range tests {
+		lapStats, _ := competitors[test.competitorID].CalculateStats(p.Config())
+		penaltyStats := competitors[test.competitorID].TotalPenaltyStats(p.Config())
+		for i, expected := range test.lapSpeeds {
+			if lapStats[i].Speed != expected {
+				t.Errorf("Competitor %d: expected lap %d speed %.3f, got %.3f", test.competitorID, i+1, expected, lapStats[i].Speed)
+			}
+		}
+		if penaltyStats.Speed != test.penaltySpeed {
+			t.Errorf("Competitor %d: expected penalty speed %.3f, got %.3f", test.competitorID, test.penaltySpeed, penaltyStats.Speed)
+		}
+	}
+
+	revisions := p.RaceState().DistanceRevisions
+	if len(revisions) != 1 || revisions[0].LapLen != 3300 || revisions[0].PreviousLapLen != 3000 ||
+		revisions[0].PenaltyLen != 165 || revisions[0].PreviousPenaltyLen != 150 {
+		t.Fatalf("Unexpected distance revisions %+v", revisions)
+	}
+
+	var report bytes.Buffer
+	if err := WriteRaceReport(&report, competitors, p.RaceState(), p.Config(), FormatJSONRace); err != nil {
+		t.Fatalf("Unexpected error writing report: %v", err)
+	}
+	expected := `"distanceRevisions": [
+      {
+        "at": "10:05:00.000",
+        "lapLen": 3300,
+        "penaltyLen": 165,
+        "previousLapLen": 3000,
+        "previousPenaltyLen": 150
+      }
+    ]`
+	if !strings.Contains(report.String(), expected) {
+		t.Errorf("Expected the distance revisions in the JSON report, got:\n%s", report.String())
+	}
+}
+
+func TestProcessEventsInvalidRemeasurement(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3000, PenaltyLen: 150}
+
+	for _, params := range []string{"", "lapLen=", "lapLen=-5", "length=3000"} {
+		event := EventLog{Time: time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC), EventID: 16, ExtraParams: params}
+
+		p := NewProcessor(config)
+		if err := p.AddEvent(event); err == nil {
+			t.Errorf("Expected an error for %q", params)
+		}
+		if p.Config().LapLen != 3000 || len(p.RaceState().DistanceRevisions) != 0 {
+			t.Errorf("Expected %q to leave the course unchanged", params)
+		}
+	}
+}
+
+func TestProcessEventsFiringRangeOccupancy(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3000, PenaltyLen: 150, FiringLines: 2}
+
+	events := parseEvents(t, []string{
+		"[09:00:00.000] 1 1",
+		"[09:00:01.000] 1 2",
+		"[09:00:02.000] 1 3",
+		"[09:00:03.000] 1 4",
+		"[10:00:00.000] 4 1",
+		"[10:00:01.000] 4 2",
+		"[10:00:02.000] 4 3",
+		"[10:00:03.000] 4 4",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:01.000] 5 2 1",
+		"[10:05:02.000] 5 3 1",
+		"[10:05:30.000] 7 1",
+		"[10:05:31.000] 11 2 Broken rifle",
+		"[10:05:32.000] 5 4 1",
+	})
+
+	p := NewProcessor(config, WithMode(Strict))
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "[10:05:02.000] event 5 for competitor(3): competitor(3) entered the firing range with 2 competitors on it, range oversubscribed"
+	if warnings := p.Warnings(); len(warnings) != 1 || warnings[0].String() != expected {
+		t.Errorf("Expected one oversubscription warning, got %v", warnings)
+	}
+
+	// Competitor 1 left the range and competitor 2 stopped on it, making
+	// room for competitor 4
+	if queue := p.RaceState().FiringRangeQueue; !reflect.DeepEqual(queue, []int{3, 4}) {
+		t.Errorf("Expected competitors 3 and 4 on the range, got %v", queue)
+	}
+}
+
+func TestProcessEventsFiringRangeRepeatedEntry(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3000, PenaltyLen: 150, FiringLines: 1}
+
+	events := parseEvents(t, []string{
+		"[09:00:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:01.000] 5 1 1",
+	})
+
+	p := NewProcessor(config, WithMode(Strict))
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if queue := p.RaceState().FiringRangeQueue; !reflect.DeepEqual(queue, []int{1}) {
+		t.Errorf("Expected competitor 1 on the range once, got %v", queue)
+	}
+	for _, warning := range p.Warnings() {
+		if strings.Contains(warning.Message, "oversubscribed") {
+			t.Errorf("Expected no oversubscription warning, got %v", warning)
+		}
+	}
+
+	if err := p.AddEvent(parseEvents(t, []string{"[10:05:30.000] 7 1"})[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if queue := p.RaceState().FiringRangeQueue; len(queue) != 0 {
+		t.Errorf("Expected the range to be empty, got %v", queue)
+	}
+}
@@ -0,0 +1,241 @@
+package biathlon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RelayLegs is the number of legs of a relay; a team has one member per leg.
+const RelayLegs = 4
+
+// RelayTeam is a relay team as built from the baton events. A competitor who
+// receives the baton for a team (event 18) skis its next leg and hands the
+// baton on (event 19) when the leg is done. The last leg ends at the finish.
+// Each member is also tracked as an individual competitor.
+type RelayTeam struct {
+	TeamID     int
+	Members    []int       // competitor IDs in leg order
+	CurrentLeg int         // 1-based leg being skied, zero before the first baton
+	Received   []time.Time // when each member received the baton
+	HandedOff  []time.Time // when each member handed it on
+}
+
+// clone returns a copy of the team that shares no slices with it.
+func (t RelayTeam) clone() RelayTeam {
+	t.Members = slices.Clone(t.Members)
+	t.Received = slices.Clone(t.Received)
+	t.HandedOff = slices.Clone(t.HandedOff)
+	return t
+}
+
+// relayTeam returns the team with the given ID, or nil. The pointer is only
+// valid until another team is added.
+func (p *Processor) relayTeam(teamID int) *RelayTeam {
+	for i := range p.race.RelayTeams {
+		if p.race.RelayTeams[i].TeamID == teamID {
+			return &p.race.RelayTeams[i]
+		}
+	}
+
+	return nil
+}
+
+// batonTeam returns the team whose baton the competitor has, or nil.
+func (p *Processor) batonTeam(competitorID int) *RelayTeam {
+	for i := range p.race.RelayTeams {
+		team := &p.race.RelayTeams[i]
+		if len(team.Members) > len(team.HandedOff) && team.Members[len(team.Members)-1] == competitorID {
+			return team
+		}
+	}
+
+	return nil
+}
+
+// receiveBaton handles event 18, "<teamID>": the competitor skis the team's
+// next leg. The previous member must have handed the baton on.
+func (p *Processor) receiveBaton(competitor *Competitor, event EventLog) error {
+	if !p.config.RelayMode {
+		return errors.New("baton events are only allowed in relay mode")
+	}
+	// The teams are race-wide and not rebuilt by a correction
+	if p.replaying {
+		return nil
+	}
+	teamID, err := strconv.Atoi(event.ExtraParams)
+	if err != nil {
+		return fmt.Errorf("invalid team %q: %w", event.ExtraParams, err)
+	}
+	for _, team := range p.race.RelayTeams {
+		if leg := slices.Index(team.Members, competitor.ID); leg >= 0 {
+			return fmt.Errorf("%s already skied leg %d of team %d", competitor.Label(), leg+1, team.TeamID)
+		}
+	}
+
+	team := p.relayTeam(teamID)
+	if team == nil {
+		p.race.RelayTeams = append(p.race.RelayTeams, RelayTeam{TeamID: teamID})
+		team = &p.race.RelayTeams[len(p.race.RelayTeams)-1]
+	}
+	if len(team.Members) > len(team.HandedOff) {
+		return fmt.Errorf("competitor(%d) still has the baton of team %d", team.Members[len(team.Members)-1], teamID)
+	}
+	if len(team.Members) == RelayLegs {
+		return fmt.Errorf("team %d already skied all %d legs", teamID, RelayLegs)
+	}
+
+	team.Members = append(team.Members, competitor.ID)
+	team.Received = append(team.Received, event.Time)
+	team.CurrentLeg = len(team.Members)
+	p.logf(slog.LevelInfo, event, "The %s took the baton for leg %d of team %d", competitor.Label(), team.CurrentLeg, teamID)
+
+	return nil
+}
+
+// handOffBaton handles event 19: the competitor's leg is done. The last leg
+// ends at the finish instead.
+func (p *Processor) handOffBaton(competitor *Competitor, event EventLog) error {
+	if !p.config.RelayMode {
+		return errors.New("baton events are only allowed in relay mode")
+	}
+	if p.replaying {
+		return nil
+	}
+	team := p.batonTeam(competitor.ID)
+	if team == nil {
+		return fmt.Errorf("%s does not have a baton to hand off", competitor.Label())
+	}
+	if team.CurrentLeg == RelayLegs {
+		return fmt.Errorf("%s skis the last leg of team %d and has nobody to hand off to", competitor.Label(), team.TeamID)
+	}
+
+	team.HandedOff = append(team.HandedOff, event.Time)
+	p.logf(slog.LevelInfo, event, "The %s handed off the baton of team %d", competitor.Label(), team.TeamID)
+
+	return nil
+}
+
+// RelayResultRow is one team's line of the relay results.
+type RelayResultRow struct {
+	TeamID     int
+	Place      int           // from 1, shared by equal finish times; zero unless Finished
+	Status     string        // Finished, Started, or the status of the member who stopped
+	FinishTime time.Time     // when the last leg finished, zero unless Finished
+	TotalTime  time.Duration // from the first baton to the finish, zero unless Finished
+	Gap        time.Duration // behind the winner, zero unless Finished
+	Legs       []RelayLegResult
+}
+
+// RelayLegResult is a member's contribution to their team's time.
+type RelayLegResult struct {
+	CompetitorID int
+	Time         time.Duration // zero until the leg is done
+}
+
+// BuildRelayResults returns one row per team, ranked by when their final leg
+// finished. Teams still racing follow by the legs they completed, then teams
+// with a member who stopped.
+func BuildRelayResults(teams []RelayTeam, competitors map[int]*Competitor) []RelayResultRow {
+	rows := make([]RelayResultRow, 0, len(teams))
+	completed := make(map[int]int)
+	for _, team := range teams {
+		row := RelayResultRow{TeamID: team.TeamID, Status: "Started"}
+		for i, id := range team.Members {
+			leg := RelayLegResult{CompetitorID: id}
+			member := competitors[id]
+			switch {
+			case i < len(team.HandedOff):
+				leg.Time = team.HandedOff[i].Sub(team.Received[i])
+			case i == RelayLegs-1 && member != nil && member.Status == "Finished":
+				leg.Time = member.FinishTime.Sub(team.Received[i])
+				row.Status = "Finished"
+				row.FinishTime = member.FinishTime
+				row.TotalTime = member.FinishTime.Sub(team.Received[0])
+			}
+			if leg.Time > 0 {
+				completed[team.TeamID]++
+			}
+			if member != nil && row.Status == "Started" {
+				switch member.Status {
+				case "NotFinished", "Withdrew", "Disqualified":
+					row.Status = member.Status
+				}
+			}
+			row.Legs = append(row.Legs, leg)
+		}
+		rows = append(rows, row)
+	}
+
+	statusPriority := map[string]int{
+		"Finished":     0,
+		"Started":      1,
+		"NotFinished":  2,
+		"Withdrew":     3,
+		"Disqualified": 4,
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		ri, rj := rows[i], rows[j]
+		if ri.Status != rj.Status {
+			return statusPriority[ri.Status] < statusPriority[rj.Status]
+		}
+		if !ri.FinishTime.Equal(rj.FinishTime) {
+			return ri.FinishTime.Before(rj.FinishTime)
+		}
+		if completed[ri.TeamID] != completed[rj.TeamID] {
+			return completed[ri.TeamID] > completed[rj.TeamID]
+		}
+		return ri.TeamID < rj.TeamID
+	})
+
+	for i := range rows {
+		if rows[i].Status != "Finished" {
+			break
+		}
+		rows[i].Gap = rows[i].FinishTime.Sub(rows[0].FinishTime)
+		rows[i].Place = i + 1
+		if i > 0 && rows[i].FinishTime.Equal(rows[i-1].FinishTime) {
+			rows[i].Place = rows[i-1].Place
+		}
+	}
+
+	return rows
+}
+
+// writeRelayResults writes the Relay section of the text report, e.g.
+// "1. [00:52:10.000] Team 3 [11 {00:13:00.000}, 12 {00:13:05.000}, ...] +00:00.000".
+// A leg not done yet has no time.
+func writeRelayResults(w io.Writer, rows []RelayResultRow) error {
+	if _, err := fmt.Fprintln(w, "\nRelay:"); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		place, status, gap := "", row.Status, "NT"
+		if row.Status == "Finished" {
+			place = strconv.Itoa(row.Place) + ". "
+			status = formatDuration(row.TotalTime)
+			gap = "+" + formatMinutes(row.Gap)
+		}
+
+		legs := make([]string, 0, len(row.Legs))
+		for _, leg := range row.Legs {
+			legTime := ""
+			if leg.Time > 0 {
+				legTime = formatDuration(leg.Time)
+			}
+			legs = append(legs, fmt.Sprintf("%d {%s}", leg.CompetitorID, legTime))
+		}
+
+		if _, err := fmt.Fprintf(w, "%s[%s] Team %d [%s] %s\n", place, status, row.TeamID, strings.Join(legs, ", "), gap); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
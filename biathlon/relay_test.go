@@ -0,0 +1,132 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// relayLegEvents returns the events of one relay leg: competitor id takes the
+// baton of the team at start and skis one lap of the given length, handing
+// the baton off at its end unless handOff is false.
+func relayLegEvents(id, team int, start time.Time, lap time.Duration, handOff bool) []string {
+	at := func(t time.Time) string { return "[" + formatTime(t) + "]" }
+	lines := []string{
+		fmt.Sprintf("%s 18 %d %d", at(start), id, team),
+		fmt.Sprintf("%s 4 %d", at(start), id),
+		fmt.Sprintf("%s 10 %d", at(start.Add(lap)), id),
+	}
+	if handOff {
+		lines = append(lines, fmt.Sprintf("%s 19 %d", at(start.Add(lap)), id))
+	}
+
+	return lines
+}
+
+func TestRelay(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, RelayMode: true}
+	start := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var lines []string
+	for id := 1; id <= 9; id++ {
+		lines = append(lines, fmt.Sprintf("[09:30:00.000] 1 %d", id))
+	}
+	// Teams 1 and 2 ski all four legs, team 2 faster; team 3 is on its
+	// second leg
+	legs1 := []time.Duration{12 * time.Minute, 13 * time.Minute, 12 * time.Minute, 14 * time.Minute}
+	legs2 := []time.Duration{12 * time.Minute, 12 * time.Minute, 12 * time.Minute, 13 * time.Minute}
+	at1, at2 := start, start
+	for leg := 0; leg < RelayLegs; leg++ {
+		lines = append(lines, relayLegEvents(leg+1, 1, at1, legs1[leg], leg < RelayLegs-1)...)
+		lines = append(lines, relayLegEvents(leg+5, 2, at2, legs2[leg], leg < RelayLegs-1)...)
+		at1, at2 = at1.Add(legs1[leg]), at2.Add(legs2[leg])
+	}
+	lines = append(lines, "[10:00:00.000] 18 9 3", "[10:00:00.000] 4 9")
+	events := parseEvents(t, lines)
+
+	p := NewProcessor(config, WithStateValidation(true))
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	competitors := p.Finalize()
+
+	race := p.RaceState()
+	if len(race.RelayTeams) != 3 || !reflect.DeepEqual(race.RelayTeams[1].Members, []int{5, 6, 7, 8}) || race.RelayTeams[1].CurrentLeg != 4 {
+		t.Fatalf("Expected team 2 of members 5 to 8 on its last leg, got %+v", race.RelayTeams)
+	}
+
+	rows := BuildRelayResults(race.RelayTeams, competitors)
+	if len(rows) != 3 {
+		t.Fatalf("Expected a row per team, got %+v", rows)
+	}
+	if rows[0].TeamID != 2 || rows[0].Place != 1 || rows[0].TotalTime != 49*time.Minute {
+		t.Errorf("Expected team 2 to win in 49 minutes, got %+v", rows[0])
+	}
+	if rows[1].TeamID != 1 || rows[1].Place != 2 || rows[1].Gap != 2*time.Minute {
+		t.Errorf("Expected team 1 second 2 minutes behind, got %+v", rows[1])
+	}
+	if rows[2].TeamID != 3 || rows[2].Status != "Started" || rows[2].Place != 0 {
+		t.Errorf("Expected team 3 still racing, got %+v", rows[2])
+	}
+	for i, leg := range rows[1].Legs {
+		if leg.CompetitorID != i+1 || leg.Time != legs1[i] {
+			t.Errorf("Expected leg %d of team 1 by competitor %d in %v, got %+v", i+1, i+1, legs1[i], leg)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRaceReport(&buf, competitors, race, config, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	expected := "\nRelay:\n" +
+		"1. [00:49:00.000] Team 2 [5 {00:12:00.000}, 6 {00:12:00.000}, 7 {00:12:00.000}, 8 {00:13:00.000}] +00:00.000\n" +
+		"2. [00:51:00.000] Team 1 [1 {00:12:00.000}, 2 {00:13:00.000}, 3 {00:12:00.000}, 4 {00:14:00.000}] +02:00.000\n" +
+		"[Started] Team 3 [9 {}] NT\n"
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Errorf("Expected the report to end with:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteRaceReport(&buf, competitors, race, config, FormatJSONRace); err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Race.Relay) != 3 || *report.Race.Relay[0].TotalTime != "00:49:00.000" || report.Race.Relay[2].Legs[0].Time != nil {
+		t.Errorf("Unexpected relay results in JSON:\n%s", buf.String())
+	}
+}
+
+func TestRelayBatonErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		relayMode bool
+		lines     []string
+		expected  string
+	}{
+		{"not in relay mode", false, []string{"[10:00:00.000] 18 1 1"}, "baton events are only allowed in relay mode"},
+		{"invalid team", true, []string{"[10:00:00.000] 18 1 A"}, `invalid team "A"`},
+		{"baton still out", true, []string{"[10:00:00.000] 18 1 1", "[10:01:00.000] 18 2 1"}, "competitor(1) still has the baton of team 1"},
+		{"two legs", true, []string{"[10:00:00.000] 18 1 1", "[10:00:00.000] 4 1", "[10:10:00.000] 19 1", "[10:10:00.000] 18 1 1"},
+			"competitor(1) already skied leg 1 of team 1"},
+		{"no baton", true, []string{"[10:00:00.000] 4 1", "[10:10:00.000] 19 1"}, "competitor(1) does not have a baton to hand off"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, RelayMode: test.relayMode}
+			lines := append([]string{"[09:30:00.000] 1 1", "[09:30:00.000] 1 2"}, test.lines...)
+			err := NewProcessor(config).AddEvents(context.Background(), parseEvents(t, lines))
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected an error containing %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
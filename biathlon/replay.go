@@ -0,0 +1,67 @@
+package biathlon
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Replay feeds the events to p in time order, waiting between events for the
+// real gap between them divided by speed, so the commentary appears as if the
+// race were live. A speed of 2 replays twice as fast; 0 does not wait at all.
+//
+// Invalid events are handled as in ProcessEvents according to p's mode.
+// Finalize is not run. Cancelling ctx stops the replay with ctx.Err().
+func Replay(ctx context.Context, events []EventLog, p *Processor, speed float64) error {
+	return replay(ctx, events, p, speed, sleep)
+}
+
+// sleep waits for d or until ctx is cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func replay(ctx context.Context, events []EventLog, p *Processor, speed float64, wait func(context.Context, time.Duration) error) error {
+	// Place the events on the right day before sorting, in case the race
+	// crosses midnight
+	sorted := make([]EventLog, len(events))
+	copy(sorted, events)
+	for i := 1; i < len(sorted); i++ {
+		sorted[i].Time = nearestDay(sorted[i].Time, sorted[i-1].Time)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	var errs []error
+	for i, event := range sorted {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if i > 0 && speed > 0 {
+			gap := event.Time.Sub(sorted[i-1].Time)
+			if err := wait(ctx, time.Duration(float64(gap)/speed)); err != nil {
+				return err
+			}
+		}
+
+		if err := p.AddEvent(event); err != nil {
+			if p.mode == Strict {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
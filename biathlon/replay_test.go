@@ -0,0 +1,85 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:10.000] 1 2",
+		"[09:30:04.000] 3 1",
+		"[09:30:30.000] 4 1",
+	})
+
+	tests := []struct {
+		speed    float64
+		expected []time.Duration
+	}{
+		{1, []time.Duration{4 * time.Second, 6 * time.Second, 20 * time.Second}},
+		{4, []time.Duration{time.Second, 1500 * time.Millisecond, 5 * time.Second}},
+		{0, nil},
+	}
+
+	for _, test := range tests {
+		var waits []time.Duration
+		wait := func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		}
+
+		var out bytes.Buffer
+		p := NewProcessor(config, WithMode(Strict), WithLogger(narrationLogger(&out)))
+		if err := replay(context.Background(), events, p, test.speed, wait); err != nil {
+			t.Fatalf("Speed %v: unexpected error: %v", test.speed, err)
+		}
+
+		if !reflect.DeepEqual(waits, test.expected) {
+			t.Errorf("Speed %v: expected waits %v, got %v", test.speed, test.expected, waits)
+		}
+
+		expected := "[09:30:00.000] The competitor(1) registered\n" +
+			"[09:30:04.000] The competitor(1) is on the start line\n" +
+			"[09:30:10.000] The competitor(2) registered\n" +
+			"[09:30:30.000] The competitor(1) has started\n"
+		if out.String() != expected {
+			t.Errorf("Speed %v: expected commentary in time order:\n%s\ngot:\n%s", test.speed, expected, out.String())
+		}
+	}
+}
+
+func TestReplayCancelled(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:30:00.000] 1 2",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := NewProcessor(config)
+	if err := replay(ctx, events, p, 1, sleep); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	p = NewProcessor(config)
+	if err := Replay(ctx, events, p, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the hour-long wait to be interrupted, got %v", err)
+	}
+
+	if len(p.Results()) != 1 {
+		t.Errorf("Expected only the first event to be replayed, got %d competitors", len(p.Results()))
+	}
+}
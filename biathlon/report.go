@@ -0,0 +1,610 @@
+package biathlon
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ReportFormat selects how WriteReport renders the final results.
+type ReportFormat string
+
+const (
+	FormatText     ReportFormat = "text"
+	FormatColor    ReportFormat = "color"     // text highlighted with ANSI escape codes
+	FormatJSON     ReportFormat = "json"      // an array of ReportEntry, without the generator
+	FormatJSONRace ReportFormat = "json-race" // a Report, with the generator and race-wide state
+	FormatCSV      ReportFormat = "csv"
+	FormatMarkdown ReportFormat = "markdown"
+	FormatHTML     ReportFormat = "html"
+	FormatXML      ReportFormat = "xml"
+)
+
+// Report is the json-race form of the final results together with the
+// race-wide state. The json format has the results only.
+type Report struct {
+	Generator string        `json:"generator,omitempty"` // Configuration.Generator
+	Race      RaceEntry     `json:"race"`
+	Results   []ReportEntry `json:"results"`
+}
+
+// RaceEntry is the JSON form of a RaceState.
+type RaceEntry struct {
+	DistanceRevisions []DistanceRevisionEntry `json:"distanceRevisions,omitempty"`
+	Relay             []RelayEntry            `json:"relay,omitempty"` // in relay mode
+}
+
+// RelayEntry is the JSON form of a RelayResultRow.
+type RelayEntry struct {
+	TeamID    int             `json:"teamID"`
+	Place     *int            `json:"place"` // null unless Finished
+	Status    string          `json:"status"`
+	TotalTime *string         `json:"totalTime"` // null unless Finished
+	Gap       *string         `json:"gap"`       // null unless Finished
+	Legs      []RelayLegEntry `json:"legs"`
+}
+
+// RelayLegEntry is the JSON form of a RelayLegResult.
+type RelayLegEntry struct {
+	CompetitorID int     `json:"competitorID"`
+	Time         *string `json:"time"` // null until the leg is done
+}
+
+// DistanceRevisionEntry is the JSON form of a DistanceRevision.
+type DistanceRevisionEntry struct {
+	At                 string `json:"at"`
+	LapLen             int    `json:"lapLen"`
+	PenaltyLen         int    `json:"penaltyLen"`
+	PreviousLapLen     int    `json:"previousLapLen"`
+	PreviousPenaltyLen int    `json:"previousPenaltyLen"`
+}
+
+type ReportEntry struct {
+	CompetitorID           int               `json:"competitorID"`
+	Name                   string            `json:"name,omitempty"`
+	Nation                 string            `json:"nation,omitempty"`
+	Bib                    int               `json:"bib,omitempty"`
+	Place                  *int              `json:"place"` // null unless Finished
+	Status                 string            `json:"status"`
+	DNFReason              string            `json:"dnfReason,omitempty"`
+	DisqualificationReason string            `json:"disqualificationReason,omitempty"`
+	TotalTime              *string           `json:"totalTime"`   // null unless Finished
+	TotalTimeMs            *int64            `json:"totalTimeMs"` // TotalTime in milliseconds
+	Gap                    *string           `json:"gap"`         // null unless Finished
+	Laps                   []*LapStats       `json:"laps"`        // one per lap, null if not completed
+	Penalties              []LapStats        `json:"penalties"`   // one per penalty segment
+	Hits                   int               `json:"hits"`
+	Shots                  int               `json:"shots"`
+	RangeAccuracy          []float64         `json:"rangeAccuracy,omitempty"`
+	Splits                 []SplitEntry      `json:"splits,omitempty"`
+	RangeVisits            []RangeVisitEntry `json:"rangeVisits,omitempty"`
+	NegativeSplits         []int             `json:"negativeSplits,omitempty"`
+	Corrections            []CorrectionEntry `json:"corrections,omitempty"`
+	Anomalies              []string          `json:"anomalies,omitempty"`
+	Resumed                bool              `json:"resumed,omitempty"`
+	ResumeReason           string            `json:"resumeReason,omitempty"`
+}
+
+// SplitEntry is the JSON form of a SplitTime.
+type SplitEntry struct {
+	CheckpointID int    `json:"checkpointID"`
+	Lap          int    `json:"lap"`
+	Time         string `json:"time"`
+	Elapsed      string `json:"elapsed"`
+	Gap          string `json:"gap"`
+}
+
+// CorrectionEntry is the JSON form of a Correction.
+type CorrectionEntry struct {
+	EventID       int    `json:"eventID"`
+	OriginalTime  string `json:"originalTime"`
+	CorrectedTime string `json:"correctedTime"`
+	Reason        string `json:"reason,omitempty"`
+	At            string `json:"at"`
+}
+
+// RangeVisitEntry is the JSON form of a RangeVisit.
+type RangeVisitEntry struct {
+	Lap         int    `json:"lap"`
+	FiringRange int    `json:"firingRange"`
+	Enter       string `json:"enter"`
+	Leave       string `json:"leave,omitempty"`
+	Duration    string `json:"duration,omitempty"`
+	Hits        int    `json:"hits"`
+	Misses      int    `json:"misses"`
+	Shots       int    `json:"shots"`
+	PenaltyTime string `json:"penaltyTime,omitempty"`
+}
+
+// WriteReport renders the final results to w in the given format.
+func WriteReport(w io.Writer, competitors map[int]*Competitor, config Configuration, format ReportFormat) error {
+	return WriteRaceReport(w, competitors, RaceState{}, config, format)
+}
+
+// WriteRaceReport is WriteReport for a race whose race-wide state is known,
+// e.g. from Processor.RaceState. Only the json-race format includes it, except
+// for the relay teams, which the text format ranks too in relay mode. The
+// other formats leave the relay teams out.
+func WriteRaceReport(w io.Writer, competitors map[int]*Competitor, race RaceState, config Configuration, format ReportFormat) error {
+	rows := BuildResults(competitors, config)
+
+	switch format {
+	case FormatText, FormatColor:
+		if err := writeTextReport(w, rows, config, format == FormatColor); err != nil {
+			return err
+		}
+		if config.RelayMode {
+			if err := writeRelayResults(w, BuildRelayResults(race.RelayTeams, competitors)); err != nil {
+				return err
+			}
+		}
+		return writeNationResults(w, competitors, config)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(newReportEntries(rows, config))
+	case FormatJSONRace:
+		report := Report{Generator: config.Generator, Results: newReportEntries(rows, config)}
+		if config.RelayMode {
+			report.Race.Relay = newRelayEntries(BuildRelayResults(race.RelayTeams, competitors))
+		}
+		for _, revision := range race.DistanceRevisions {
+			report.Race.DistanceRevisions = append(report.Race.DistanceRevisions, DistanceRevisionEntry{
+				At:                 formatTime(revision.At),
+				LapLen:             revision.LapLen,
+				PenaltyLen:         revision.PenaltyLen,
+				PreviousLapLen:     revision.PreviousLapLen,
+				PreviousPenaltyLen: revision.PreviousPenaltyLen,
+			})
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatCSV:
+		return writeCSVReport(w, rows, config)
+	case FormatMarkdown:
+		return writeMarkdownReport(w, rows, config)
+	case FormatHTML:
+		return writeHTMLReport(w, rows, config, nil)
+	case FormatXML:
+		return writeXMLReport(w, rows, config)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+func newRelayEntries(rows []RelayResultRow) []RelayEntry {
+	entries := make([]RelayEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := RelayEntry{TeamID: row.TeamID, Status: row.Status}
+		if row.Status == "Finished" {
+			place, totalTime, gap := row.Place, formatDuration(row.TotalTime), "+"+formatMinutes(row.Gap)
+			entry.Place, entry.TotalTime, entry.Gap = &place, &totalTime, &gap
+		}
+		for _, leg := range row.Legs {
+			legEntry := RelayLegEntry{CompetitorID: leg.CompetitorID}
+			if leg.Time > 0 {
+				legTime := formatDuration(leg.Time)
+				legEntry.Time = &legTime
+			}
+			entry.Legs = append(entry.Legs, legEntry)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// BuildReportEntries returns one entry per competitor in final standings order.
+func BuildReportEntries(competitors map[int]*Competitor, config Configuration) []ReportEntry {
+	return newReportEntries(BuildResults(competitors, config), config)
+}
+
+func newReportEntries(rows []ResultRow, config Configuration) []ReportEntry {
+	entries := make([]ReportEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := ReportEntry{
+			CompetitorID:           row.CompetitorID,
+			Name:                   row.Name,
+			Nation:                 row.Nation,
+			Bib:                    row.Bib,
+			Status:                 row.Status,
+			DNFReason:              row.DNFReason,
+			DisqualificationReason: row.DisqualificationReason,
+			Laps:                   make([]*LapStats, max(config.Laps, len(row.Laps))),
+			Hits:                   row.Hits,
+			Shots:                  row.Shots,
+			RangeAccuracy:          row.RangeAccuracy,
+			NegativeSplits:         row.NegativeSplits,
+			Anomalies:              row.Anomalies,
+			Resumed:                row.Resumed,
+			ResumeReason:           row.ResumeReason,
+		}
+
+		for i := range row.Laps {
+			entry.Laps[i] = &row.Laps[i]
+		}
+		entry.Penalties = append([]LapStats{}, row.Penalties...)
+
+		if row.Status == "Finished" {
+			place, totalTime, totalTimeMs, gap := row.Place, formatDuration(row.TotalTime), row.TotalTime.Milliseconds(), formatGap(row)
+			entry.Place, entry.TotalTime, entry.TotalTimeMs, entry.Gap = &place, &totalTime, &totalTimeMs, &gap
+		}
+
+		for _, visit := range row.RangeVisits {
+			visitEntry := RangeVisitEntry{
+				Lap:         visit.Lap,
+				FiringRange: visit.FiringRange,
+				Enter:       formatTime(visit.Enter),
+				Hits:        visit.Hits,
+				Misses:      visit.Misses,
+				Shots:       boutShots(visit, config),
+			}
+			if !visit.Leave.IsZero() {
+				visitEntry.Leave = formatTime(visit.Leave)
+				visitEntry.Duration = formatDuration(visit.Leave.Sub(visit.Enter))
+			}
+			if visit.PenaltyTime > 0 {
+				visitEntry.PenaltyTime = formatDuration(visit.PenaltyTime)
+			}
+			entry.RangeVisits = append(entry.RangeVisits, visitEntry)
+		}
+
+		for _, correction := range row.Corrections {
+			entry.Corrections = append(entry.Corrections, CorrectionEntry{
+				EventID:       correction.EventID,
+				OriginalTime:  formatTime(correction.OriginalTime),
+				CorrectedTime: formatTime(correction.CorrectedTime),
+				Reason:        correction.Reason,
+				At:            formatTime(correction.At),
+			})
+		}
+
+		for _, split := range row.Splits {
+			entry.Splits = append(entry.Splits, SplitEntry{
+				CheckpointID: split.CheckpointID,
+				Lap:          split.Lap,
+				Time:         formatTime(split.At),
+				Elapsed:      formatDuration(split.Elapsed),
+				Gap:          formatDuration(split.Gap),
+			})
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// placePrefix returns "<place>. " for finishers and nothing for the others.
+func placePrefix(row ResultRow) string {
+	if row.Place == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(row.Place) + ". "
+}
+
+// boutShots returns the shots fired in a range visit. A competitor still on
+// the range has a full bout ahead of them.
+func boutShots(visit RangeVisit, config Configuration) int {
+	return max(visit.Hits+visit.Misses, config.TargetsPerLineOrDefault())
+}
+
+// formatBouts lists the hits of every range visit, e.g. "R1 4/5, R2 5/5".
+func formatBouts(visits []RangeVisit, config Configuration) string {
+	bouts := make([]string, 0, len(visits))
+	for _, visit := range visits {
+		bouts = append(bouts, fmt.Sprintf("R%d %d/%d", visit.FiringRange, visit.Hits, boutShots(visit, config)))
+	}
+
+	return strings.Join(bouts, ", ")
+}
+
+// formatGap returns the time behind the winner as "+MM:SS.sss", or "NT"
+// (no time) for competitors who did not finish.
+func formatGap(row ResultRow) string {
+	if row.Status != "Finished" {
+		return "NT"
+	}
+
+	return "+" + formatMinutes(row.Gap)
+}
+
+// writeTextReport writes one line per competitor. With color, the status is
+// highlighted by statusColor and the fastest lap of the race is bold.
+func writeTextReport(w io.Writer, rows []ResultRow, config Configuration, color bool) error {
+	if _, err := fmt.Fprintln(w, "\nFinal Results:"); err != nil {
+		return err
+	}
+
+	var fastestLap time.Duration
+	for _, row := range rows {
+		for _, lap := range row.Laps {
+			if fastestLap == 0 || lap.Duration < fastestLap {
+				fastestLap = lap.Duration
+			}
+		}
+	}
+
+	for _, row := range rows {
+		formattedLapStats := make([]string, 0)
+		for i := 0; i < len(row.Laps); i++ {
+			lapStats := fmt.Sprintf("{%s, %s}", row.Laps[i].Time, config.FormatSpeed(row.Laps[i].Speed))
+			if color && row.Laps[i].Duration == fastestLap {
+				lapStats = Colorize(lapStats, ColorBold)
+			}
+			formattedLapStats = append(formattedLapStats, lapStats)
+		}
+
+		for i := len(row.Laps); i < config.Laps; i++ {
+			formattedLapStats = append(formattedLapStats, "{,}")
+		}
+
+		formattedPenaltyStats := "{,}"
+		if len(row.Penalties) > 0 {
+			segments := make([]string, 0, len(row.Penalties))
+			for _, segment := range row.Penalties {
+				segments = append(segments, fmt.Sprintf("{%s, %s}", segment.Time, config.FormatSpeed(segment.Speed)))
+			}
+			formattedPenaltyStats = strings.Join(segments, ", ")
+		}
+
+		statusStr := row.Status
+		if row.Status == "Finished" {
+			statusStr = formatDuration(row.TotalTime)
+		} else if row.DisqualificationReason != "" {
+			statusStr += " (" + row.DisqualificationReason + ")"
+		}
+		statusStr = "[" + statusStr + "]"
+		if code := statusColor(row.Status); color && code != 0 {
+			statusStr = Colorize(statusStr, code)
+		}
+
+		// An asterisk marks results changed by an official time correction
+		competitorStr := strconv.Itoa(row.CompetitorID)
+		if len(row.Corrections) > 0 {
+			competitorStr += "*"
+		}
+		if row.Name != "" {
+			competitorStr += " " + row.Name
+		}
+
+		shooting := fmt.Sprintf("%d/%d", row.Hits, row.Shots)
+		if bouts := formatBouts(row.RangeVisits, config); bouts != "" {
+			shooting += " (" + bouts + ")"
+		}
+
+		line := fmt.Sprintf("%s%s %s [%s] %s %s %s",
+			placePrefix(row),
+			statusStr,
+			competitorStr,
+			strings.Join(formattedLapStats, ", "),
+			formattedPenaltyStats,
+			shooting,
+			formatGap(row))
+
+		if len(row.NegativeSplits) > 0 {
+			laps := make([]string, 0, len(row.NegativeSplits))
+			for _, lap := range row.NegativeSplits {
+				laps = append(laps, strconv.Itoa(lap))
+			}
+			line += fmt.Sprintf(" (negative split on laps %s)", strings.Join(laps, ", "))
+		}
+
+		if len(row.Anomalies) > 0 {
+			line += " (needs review)"
+		}
+
+		if row.Resumed {
+			line += fmt.Sprintf(" (resumed: %s)", row.ResumeReason)
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+
+		for _, split := range row.Splits {
+			if _, err := fmt.Fprintf(w, "    lap %d checkpoint %d: %s +%s\n",
+				split.Lap, split.CheckpointID, formatDuration(split.Elapsed), formatDuration(split.Gap)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := writeTextAppendix(w, "Corrections", rows, func(row ResultRow) []string {
+		lines := make([]string, 0, len(row.Corrections))
+		for _, correction := range row.Corrections {
+			line := fmt.Sprintf("%d*: event %d at %s corrected to %s at %s",
+				row.CompetitorID, correction.EventID, formatTime(correction.OriginalTime),
+				formatTime(correction.CorrectedTime), formatTime(correction.At))
+			if correction.Reason != "" {
+				line += " (" + correction.Reason + ")"
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}); err != nil {
+		return err
+	}
+
+	return writeTextAppendix(w, "Needs review", rows, func(row ResultRow) []string {
+		lines := make([]string, 0, len(row.Anomalies))
+		for _, anomaly := range row.Anomalies {
+			lines = append(lines, fmt.Sprintf("%d: %s", row.CompetitorID, anomaly))
+		}
+		return lines
+	})
+}
+
+// writeTextAppendix writes a section with the given title listing the lines
+// of all rows, unless there are none.
+func writeTextAppendix(w io.Writer, title string, rows []ResultRow, lines func(ResultRow) []string) error {
+	header := false
+	for _, row := range rows {
+		for _, line := range lines(row) {
+			if !header {
+				if _, err := fmt.Fprintf(w, "\n%s:\n", title); err != nil {
+					return err
+				}
+				header = true
+			}
+
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeCSVReport writes one record per competitor under a header row. The
+// Configuration.Generator, if set, comes first on a "# generator: " comment
+// line, which a csv.Reader with Comment set to '#' skips.
+func writeCSVReport(w io.Writer, rows []ResultRow, config Configuration) error {
+	if config.Generator != "" {
+		if _, err := fmt.Fprintf(w, "# generator: %s\n", config.Generator); err != nil {
+			return err
+		}
+	}
+
+	writer := csv.NewWriter(w)
+
+	// The header only depends on the configuration, so every competitor has
+	// a column for every lap whether they completed it or not
+	header := []string{"place", "competitorID", "name", "status", "reason", "totalTime", "gap"}
+	for i := 1; i <= config.Laps; i++ {
+		header = append(header, fmt.Sprintf("lap%d_time", i), fmt.Sprintf("lap%d_speed", i), fmt.Sprintf("lap%d_penalty", i))
+	}
+	header = append(header, "penaltyTime", "penaltySpeed", "hits", "shots", "bouts")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		placeStr := ""
+		if row.Place > 0 {
+			placeStr = strconv.Itoa(row.Place)
+		}
+
+		totalTime := ""
+		if row.Status == "Finished" {
+			totalTime = formatDuration(row.TotalTime)
+		}
+
+		reason := row.DNFReason
+		if row.Status == "Disqualified" {
+			reason = row.DisqualificationReason
+		}
+
+		record := []string{placeStr, strconv.Itoa(row.CompetitorID), row.Name, row.Status, reason, totalTime, formatGap(row)}
+		for i := 0; i < config.Laps; i++ {
+			if i < len(row.Laps) {
+				record = append(record, row.Laps[i].Time, config.FormatSpeed(row.Laps[i].Speed), row.Laps[i].PenaltyTime)
+			} else {
+				record = append(record, "", "", "")
+			}
+		}
+
+		penaltySpeed := ""
+		if row.Penalty.Time != "" {
+			penaltySpeed = config.FormatSpeed(row.Penalty.Speed)
+		}
+		record = append(record, row.Penalty.Time, penaltySpeed, strconv.Itoa(row.Hits), strconv.Itoa(row.Shots),
+			formatBouts(row.RangeVisits, config))
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// markdownLapColumns is the most laps shown in a column each; longer races
+// list all lap times in one column.
+const markdownLapColumns = 4
+
+// writeMarkdownReport writes the standings as a Markdown table, padded so the
+// raw text lines up as well.
+func writeMarkdownReport(w io.Writer, rows []ResultRow, config Configuration) error {
+	// Laps skied beyond config.Laps are shown too, as in the JSON report
+	laps := config.Laps
+	for _, row := range rows {
+		laps = max(laps, len(row.Laps))
+	}
+
+	header := []string{"Place", "Competitor", "Total time"}
+	if laps <= markdownLapColumns {
+		for i := 1; i <= laps; i++ {
+			header = append(header, fmt.Sprintf("Lap %d", i))
+		}
+	} else {
+		header = append(header, "Laps")
+	}
+	header = append(header, "Penalty", "Shooting")
+
+	table := [][]string{header}
+	for _, row := range rows {
+		placeStr, totalTime := "", row.Status
+		if row.Status == "Finished" {
+			placeStr, totalTime = strconv.Itoa(row.Place), formatDuration(row.TotalTime)
+		}
+
+		competitorStr := strconv.Itoa(row.CompetitorID)
+		if row.Name != "" {
+			competitorStr += " " + strings.ReplaceAll(row.Name, "|", `\|`)
+		}
+
+		lapTimes := make([]string, laps)
+		for i := range row.Laps {
+			lapTimes[i] = row.Laps[i].Time
+		}
+		record := []string{placeStr, competitorStr, totalTime}
+		if laps <= markdownLapColumns {
+			record = append(record, lapTimes...)
+		} else {
+			record = append(record, strings.Join(lapTimes[:len(row.Laps)], " / "))
+		}
+		record = append(record, row.Penalty.Time, fmt.Sprintf("%d/%d", row.Hits, row.Shots))
+
+		table = append(table, record)
+	}
+
+	// Separator cells need at least three dashes
+	widths := make([]int, len(header))
+	for _, record := range table {
+		for i, cell := range record {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell), 3)
+		}
+	}
+
+	for i, record := range table {
+		cells := make([]string, len(record))
+		for j, cell := range record {
+			cells[j] = cell + strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
+			return err
+		}
+
+		if i == 0 {
+			for j := range cells {
+				cells[j] = strings.Repeat("-", widths[j])
+			}
+			if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
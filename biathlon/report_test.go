@@ -0,0 +1,376 @@
+package biathlon
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildReportEntriesJSON(t *testing.T) {
+	config := Configuration{
+		Laps:       2,
+		LapLen:     3500,
+		PenaltyLen: 150,
+	}
+
+	start, _ := parseTime("[10:00:00.000]")
+	competitors := map[int]*Competitor{
+		1: {
+			ID:               1,
+			Status:           "Finished",
+			PlannedStartTime: start,
+			ActualStartTime:  start,
+			FinishTime:       start.Add(22 * time.Minute),
+			LapTimes:         []time.Duration{10 * time.Minute, 12 * time.Minute},
+			Hits:             5,
+			Shots:            5,
+		},
+		2: {
+			ID:       2,
+			Status:   "NotFinished",
+			LapTimes: []time.Duration{11 * time.Minute},
+			Hits:     3,
+			Shots:    3,
+		},
+	}
+
+	entries := BuildReportEntries(competitors, config)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 report entries, got %d", len(entries))
+	}
+
+	if entries[0].CompetitorID != 1 || entries[0].TotalTime == nil || *entries[0].TotalTime != "00:22:00.000" ||
+		entries[0].TotalTimeMs == nil || *entries[0].TotalTimeMs != 1320000 {
+		t.Errorf("Expected finisher 1 with total time 00:22:00.000 first, got %+v", entries[0])
+	}
+
+	if entries[1].CompetitorID != 2 || entries[1].TotalTime != nil {
+		t.Errorf("Expected non-finisher 2 without total time second, got %+v", entries[1])
+	}
+
+	data, err := json.Marshal(entries[1])
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling entry: %v", err)
+	}
+
+	expected := `{"competitorID":2,"place":null,"status":"NotFinished","totalTime":null,"totalTimeMs":null,"gap":null,` +
+		`"laps":[{"time":"00:11:00.000","speed":5.303030303030303},null],"penalties":[],"hits":3,"shots":3}`
+	if string(data) != expected {
+		t.Errorf("Expected JSON %s, got %s", expected, string(data))
+	}
+}
+
+func TestWriteReportCSV(t *testing.T) {
+	config := Configuration{
+		Laps:       2,
+		LapLen:     3500,
+		PenaltyLen: 150,
+	}
+
+	start, _ := parseTime("[10:00:00.000]")
+	competitors := map[int]*Competitor{
+		1: {
+			ID:                1,
+			Status:            "Finished",
+			PlannedStartTime:  start,
+			ActualStartTime:   start,
+			FinishTime:        start.Add(22 * time.Minute),
+			LapTimes:          []time.Duration{10 * time.Minute, 12 * time.Minute},
+			TotalPenaltyTime:  2 * time.Minute,
+			PenaltyTimePerLap: []time.Duration{0, 2 * time.Minute},
+			Hits:              4,
+			Shots:             5,
+			RangeVisits: []RangeVisit{
+				{Lap: 1, FiringRange: 1, Enter: start.Add(10 * time.Minute), Leave: start.Add(11 * time.Minute), Hits: 4, Misses: 1},
+			},
+		},
+		2: {
+			ID:          2,
+			Name:        "Anna Svensson",
+			Status:      "NotFinished",
+			DNFReason:   "Lost in the forest, twisted ankle",
+			LapTimes:    []time.Duration{11 * time.Minute},
+			Hits:        3,
+			Shots:       3,
+			RangeVisits: []RangeVisit{{Lap: 1, FiringRange: 1, Enter: start.Add(11 * time.Minute), Hits: 3}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatCSV); err != nil {
+		t.Fatalf("Unexpected error writing CSV report: %v", err)
+	}
+
+	expected := "place,competitorID,name,status,reason,totalTime,gap,lap1_time,lap1_speed,lap1_penalty,lap2_time,lap2_speed,lap2_penalty,penaltyTime,penaltySpeed,hits,shots,bouts\n" +
+		"1,1,,Finished,,00:22:00.000,+00:00.000,00:10:00.000,5.833,,00:12:00.000,4.861,00:02:00.000,00:02:00.000,1.250,4,5,R1 4/5\n" +
+		",2,Anna Svensson,NotFinished,\"Lost in the forest, twisted ankle\",,NT,00:11:00.000,5.303,,,,,,,3,3,R1 3/5\n"
+	if buf.String() != expected {
+		t.Errorf("Expected CSV:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteReportGenerator(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, Generator: "biathlon 1.4.0 (commit 3f2a9c1, built 2025-06-01)"}
+	competitors := map[int]*Competitor{
+		1: {ID: 1, Status: "NotStarted"},
+	}
+
+	tests := []struct {
+		format   ReportFormat
+		expected string
+	}{
+		{FormatJSONRace, `"generator": "biathlon 1.4.0 (commit 3f2a9c1, built 2025-06-01)"`},
+		{FormatCSV, "# generator: biathlon 1.4.0 (commit 3f2a9c1, built 2025-06-01)\nplace,"},
+		{FormatXML, `<Race generator="biathlon 1.4.0 (commit 3f2a9c1, built 2025-06-01)"`},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := WriteReport(&buf, competitors, config, test.format); err != nil {
+			t.Fatalf("Unexpected error writing %s report: %v", test.format, err)
+		}
+		if !strings.Contains(buf.String(), test.expected) {
+			t.Errorf("Expected the %s report to contain %q, got:\n%s", test.format, test.expected, buf.String())
+		}
+	}
+}
+
+func TestWriteReportJSONFormats(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, Generator: "biathlon dev"}
+	competitors := map[int]*Competitor{
+		1: {ID: 1, Status: "NotStarted"},
+	}
+	race := RaceState{DistanceRevisions: []DistanceRevision{{LapLen: 3300, PenaltyLen: 150, PreviousLapLen: 3500, PreviousPenaltyLen: 150}}}
+
+	var buf bytes.Buffer
+	if err := WriteRaceReport(&buf, competitors, race, config, FormatJSON); err != nil {
+		t.Fatalf("Unexpected error writing json report: %v", err)
+	}
+	var entries []ReportEntry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil || len(entries) != 1 {
+		t.Errorf("Expected the json report to be an array of one entry, got %v:\n%s", err, buf.String())
+	}
+	if strings.Contains(buf.String(), "biathlon dev") {
+		t.Errorf("Expected only the json-race report to name the generator, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteRaceReport(&buf, competitors, race, config, FormatJSONRace); err != nil {
+		t.Fatalf("Unexpected error writing json-race report: %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Unexpected error reading json-race report: %v", err)
+	}
+	if report.Generator != "biathlon dev" || len(report.Race.DistanceRevisions) != 1 || len(report.Results) != 1 {
+		t.Errorf("Unexpected json-race report:\n%s", buf.String())
+	}
+}
+
+func TestWriteReportTextNames(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	competitors := map[int]*Competitor{
+		1: {ID: 1, Name: "Anna Svensson", Status: "NotStarted"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing text report: %v", err)
+	}
+
+	expected := "\nFinal Results:\n[NotStarted] 1 Anna Svensson [{,}] {,} 0/0 NT\n"
+	if buf.String() != expected {
+		t.Errorf("Expected text report %q, got %q", expected, buf.String())
+	}
+}
+
+func TestBuildReportEntriesGap(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	start, _ := parseTime("[10:00:00.000]")
+	competitors := map[int]*Competitor{
+		1: {
+			ID:               1,
+			Status:           "Finished",
+			PlannedStartTime: start,
+			ActualStartTime:  start,
+			FinishTime:       start.Add(10 * time.Minute),
+			LapTimes:         []time.Duration{10 * time.Minute},
+		},
+		2: {
+			ID:               2,
+			Status:           "Finished",
+			PlannedStartTime: start,
+			ActualStartTime:  start,
+			FinishTime:       start.Add(11 * time.Minute),
+			LapTimes:         []time.Duration{11 * time.Minute},
+		},
+		3: {ID: 3, Status: "NotFinished"},
+	}
+
+	entries := BuildReportEntries(competitors, config)
+	expected := map[int]string{1: "+00:00.000", 2: "+01:00.000", 3: ""}
+	for _, entry := range entries {
+		gap := ""
+		if entry.Gap != nil {
+			gap = *entry.Gap
+		}
+		if gap != expected[entry.CompetitorID] {
+			t.Errorf("Expected competitor %d gap %q, got %q", entry.CompetitorID, expected[entry.CompetitorID], gap)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing text report: %v", err)
+	}
+
+	for _, want := range []string{" 0/0 +01:00.000\n", " 0/0 NT\n"} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("Expected text report to contain %q, got %q", want, buf.String())
+		}
+	}
+}
+
+func TestWriteReportNegativeSplits(t *testing.T) {
+	config := Configuration{Laps: 3, LapLen: 3500, PenaltyLen: 150}
+
+	competitors := map[int]*Competitor{
+		1: {
+			ID:       1,
+			Status:   "NotFinished",
+			LapTimes: []time.Duration{10 * time.Minute, 9 * time.Minute, 9 * time.Minute},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatText); err != nil {
+		t.Fatalf("Unexpected error writing text report: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), " NT (negative split on laps 2, 3)\n") {
+		t.Errorf("Expected negative split note, got %q", buf.String())
+	}
+
+	data, err := json.Marshal(BuildReportEntries(competitors, config)[0])
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling entry: %v", err)
+	}
+	if !strings.Contains(string(data), `"negativeSplits":[2,3]`) {
+		t.Errorf("Expected negativeSplits in JSON, got %s", data)
+	}
+}
+
+func TestBuildResultsOrder(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	start, _ := parseTime("[10:00:00.000]")
+	finished := func(id int, total time.Duration) *Competitor {
+		return &Competitor{
+			ID:              id,
+			Status:          "Finished",
+			ActualStartTime: start,
+			FinishTime:      start.Add(total),
+			LapTimes:        []time.Duration{total},
+		}
+	}
+	competitors := map[int]*Competitor{
+		9: {ID: 9, Status: "NotStarted"},
+		3: {ID: 3, Status: "NotStarted"},
+		8: finished(8, 20*time.Minute),
+		4: finished(4, 20*time.Minute),
+		6: finished(6, 19*time.Minute),
+		7: {ID: 7, Status: "Disqualified"},
+		5: {ID: 5, Status: "NotFinished"},
+		2: {ID: 2, Status: "Started", ActualStartTime: start},
+	}
+
+	// Finishers by time, then Started, NotFinished, Disqualified and
+	// NotStarted, with ties broken by ID
+	expected := []int{6, 4, 8, 2, 5, 7, 3, 9}
+	for run := 0; run < 20; run++ {
+		var order []int
+		for _, row := range BuildResults(competitors, config) {
+			order = append(order, row.CompetitorID)
+		}
+		if !reflect.DeepEqual(order, expected) {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+	}
+}
+
+func TestBuildReportEntriesDisqualificationReason(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+	competitors := map[int]*Competitor{
+		1: {ID: 1, Status: "Disqualified", DisqualificationReason: "started outside allowed window: planned 10:00:00.000, actual 10:05:00.000"},
+	}
+
+	data, err := json.Marshal(BuildReportEntries(competitors, config)[0])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `"disqualificationReason":"started outside allowed window: planned 10:00:00.000, actual 10:05:00.000"`) {
+		t.Errorf("Expected the disqualification reason in the JSON report, got %s", data)
+	}
+}
+
+func TestWriteReportMarkdownCondensedLaps(t *testing.T) {
+	config := Configuration{Laps: 5, LapLen: 3000, PenaltyLen: 150}
+	competitors := map[int]*Competitor{
+		1: {
+			ID:       1,
+			Name:     "Ålander | Team A",
+			Status:   "NotFinished",
+			LapTimes: []time.Duration{10 * time.Minute, 11 * time.Minute},
+			Hits:     8,
+			Shots:    10,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, competitors, config, FormatMarkdown); err != nil {
+		t.Fatalf("Unexpected error writing Markdown report: %v", err)
+	}
+
+	expected := "| Place | Competitor          | Total time  | Laps                        | Penalty | Shooting |\n" +
+		"| ----- | ------------------- | ----------- | --------------------------- | ------- | -------- |\n" +
+		"|       | 1 Ålander \\| Team A | NotFinished | 00:10:00.000 / 00:11:00.000 |         | 8/10     |\n"
+	if buf.String() != expected {
+		t.Errorf("Expected Markdown:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteReportMarkdownExtraLaps(t *testing.T) {
+	tests := []struct {
+		laps     int
+		lapTimes []time.Duration
+		expected string
+	}{
+		{1, []time.Duration{10 * time.Minute, 11 * time.Minute},
+			"| Place | Competitor | Total time  | Lap 1        | Lap 2        | Penalty | Shooting |\n" +
+				"| ----- | ---------- | ----------- | ------------ | ------------ | ------- | -------- |\n" +
+				"|       | 1          | NotFinished | 00:10:00.000 | 00:11:00.000 |         | 0/0      |\n"},
+		{5, []time.Duration{time.Minute, time.Minute, time.Minute, time.Minute, time.Minute, time.Minute},
+			"| Place | Competitor | Total time  | Laps                                                                                    | Penalty | Shooting |\n" +
+				"| ----- | ---------- | ----------- | --------------------------------------------------------------------------------------- | ------- | -------- |\n" +
+				"|       | 1          | NotFinished | 00:01:00.000 / 00:01:00.000 / 00:01:00.000 / 00:01:00.000 / 00:01:00.000 / 00:01:00.000 |         | 0/0      |\n"},
+	}
+
+	for _, test := range tests {
+		config := Configuration{Laps: test.laps, LapLen: 3000, PenaltyLen: 150}
+		competitors := map[int]*Competitor{
+			1: {ID: 1, Status: "NotFinished", LapTimes: test.lapTimes},
+		}
+
+		var buf bytes.Buffer
+		if err := WriteReport(&buf, competitors, config, FormatMarkdown); err != nil {
+			t.Fatalf("Unexpected error writing Markdown report: %v", err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("%d laps: expected Markdown:\n%s\ngot:\n%s", test.laps, test.expected, buf.String())
+		}
+	}
+}
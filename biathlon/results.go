@@ -0,0 +1,119 @@
+package biathlon
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// ResultRow is one competitor's line of the final results.
+type ResultRow struct {
+	CompetitorID           int
+	Name                   string
+	Nation                 string
+	Bib                    int
+	Place                  int // from 1, shared by equal times; zero unless Finished
+	Status                 string
+	DNFReason              string // event 11 comment for NotFinished and Withdrew
+	DisqualificationReason string
+	TotalTime              time.Duration // zero unless Finished
+	Gap                    time.Duration // behind the winner, zero unless Finished
+	Laps                   []LapStats
+	Penalty                LapStats   // all penalty laps combined
+	Penalties              []LapStats // one per penalty segment
+	Hits                   int
+	Shots                  int
+	RangeAccuracy          []float64
+	Splits                 []SplitTime
+	RangeVisits            []RangeVisit
+	NegativeSplits         []int // 1-based laps faster than the first
+	Corrections            []Correction
+	Anomalies              []string
+	Resumed                bool
+	ResumeReason           string
+}
+
+// BuildResults returns one row per competitor in final standings order.
+func BuildResults(competitors map[int]*Competitor, config Configuration) []ResultRow {
+	var sortedCompetitors []*Competitor
+	for _, competitor := range competitors {
+		sortedCompetitors = append(sortedCompetitors, competitor)
+	}
+
+	// Finishers come first by total time, then competitors still racing,
+	// NotFinished, Withdrew, Disqualified and NotStarted. Ties go to the lower
+	// ID.
+	statusPriority := map[string]int{
+		"Finished":     0,
+		"Started":      1,
+		"NotFinished":  2,
+		"Withdrew":     3,
+		"Disqualified": 4,
+		"NotStarted":   5,
+	}
+	sort.SliceStable(sortedCompetitors, func(i, j int) bool {
+		ci, cj := sortedCompetitors[i], sortedCompetitors[j]
+
+		if ci.Status != cj.Status {
+			return statusPriority[ci.Status] < statusPriority[cj.Status]
+		}
+		if ci.Status == "Finished" && ci.TotalRaceTime() != cj.TotalRaceTime() {
+			return ci.TotalRaceTime() < cj.TotalRaceTime()
+		}
+
+		return ci.ID < cj.ID
+	})
+
+	rows := make([]ResultRow, 0, len(sortedCompetitors))
+	for _, competitor := range sortedCompetitors {
+		lapStats, penaltyStats := competitor.CalculateStats(config)
+
+		row := ResultRow{
+			CompetitorID:           competitor.ID,
+			Name:                   competitor.Name,
+			Nation:                 competitor.Nation,
+			Bib:                    competitor.Bib,
+			Status:                 competitor.Status,
+			DNFReason:              competitor.DNFReason,
+			DisqualificationReason: competitor.DisqualificationReason,
+			Laps:                   lapStats,
+			Penalty:                competitor.TotalPenaltyStats(config),
+			Penalties:              penaltyStats,
+			Hits:                   competitor.Hits,
+			Shots:                  competitor.Shots,
+			TotalTime:              competitor.TotalRaceTime(),
+			RangeAccuracy:          competitor.RangeAccuracy,
+			Splits:                 competitor.Splits,
+			RangeVisits:            competitor.RangeVisits,
+			NegativeSplits:         competitor.DetectNegativeSplits(),
+			Corrections:            competitor.Corrections,
+			Anomalies:              competitor.Anomalies,
+			Resumed:                competitor.Resumed,
+			ResumeReason:           competitor.ResumeReason,
+		}
+
+		rows = append(rows, row)
+	}
+
+	// Finishers are sorted first, so the first row holds the winner. Equal
+	// times share a place and the places after them are skipped, e.g. 1, 1, 3
+	for i := range rows {
+		if rows[i].Status != "Finished" {
+			break
+		}
+		rows[i].Gap = rows[i].TotalTime - rows[0].TotalTime
+		rows[i].Place = i + 1
+		if i > 0 && rows[i].TotalTime == rows[i-1].TotalTime {
+			rows[i].Place = rows[i-1].Place
+		}
+	}
+
+	// Filtered only now, so places and gaps are those in the whole field
+	if len(config.Competitors) > 0 {
+		rows = slices.DeleteFunc(rows, func(row ResultRow) bool {
+			return !slices.Contains(config.Competitors, row.CompetitorID)
+		})
+	}
+
+	return rows
+}
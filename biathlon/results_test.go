@@ -0,0 +1,134 @@
+package biathlon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildResults(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	start, _ := parseTime("[10:00:00.000]")
+	competitors := map[int]*Competitor{
+		1: {ID: 1, Status: "NotStarted"},
+		2: {
+			ID:               2,
+			Status:           "Finished",
+			PlannedStartTime: start,
+			ActualStartTime:  start,
+			FinishTime:       start.Add(12 * time.Minute),
+			LapTimes:         []time.Duration{12 * time.Minute},
+			Hits:             5,
+			Shots:            5,
+		},
+		3: {
+			ID:               3,
+			Status:           "Finished",
+			PlannedStartTime: start,
+			ActualStartTime:  start,
+			FinishTime:       start.Add(11 * time.Minute),
+			LapTimes:         []time.Duration{11 * time.Minute},
+			Hits:             4,
+			Shots:            5,
+		},
+	}
+
+	rows := BuildResults(competitors, config)
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(rows))
+	}
+
+	expected := []struct {
+		id        int
+		totalTime time.Duration
+	}{
+		{3, 11 * time.Minute},
+		{2, 12 * time.Minute},
+		{1, 0},
+	}
+
+	for i, e := range expected {
+		if rows[i].CompetitorID != e.id || rows[i].TotalTime != e.totalTime {
+			t.Errorf("Row %d: expected competitor %d with total time %v, got %d with %v",
+				i, e.id, e.totalTime, rows[i].CompetitorID, rows[i].TotalTime)
+		}
+	}
+
+	if rows[0].Hits != 4 || rows[0].Shots != 5 || len(rows[0].Laps) != 1 {
+		t.Errorf("Unexpected first row: %+v", rows[0])
+	}
+}
+
+func TestBuildResultsSharedPlaces(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	start, _ := parseTime("[10:00:00.000]")
+	finisher := func(id int, totalTime time.Duration) *Competitor {
+		return &Competitor{
+			ID:              id,
+			Status:          "Finished",
+			ActualStartTime: start,
+			FinishTime:      start.Add(totalTime),
+			LapTimes:        []time.Duration{totalTime},
+		}
+	}
+	competitors := map[int]*Competitor{
+		1: finisher(1, 12*time.Minute),
+		2: finisher(2, 11*time.Minute),
+		3: finisher(3, 11*time.Minute),
+		4: {ID: 4, Status: "NotFinished"},
+	}
+
+	rows := BuildResults(competitors, config)
+
+	expected := []struct {
+		id    int
+		place int
+		gap   time.Duration
+	}{
+		{2, 1, 0},
+		{3, 1, 0},
+		{1, 3, time.Minute},
+		{4, 0, 0},
+	}
+
+	for i, e := range expected {
+		if rows[i].CompetitorID != e.id || rows[i].Place != e.place || rows[i].Gap != e.gap {
+			t.Errorf("Row %d: expected competitor %d in place %d with gap %v, got %d in place %d with gap %v",
+				i, e.id, e.place, e.gap, rows[i].CompetitorID, rows[i].Place, rows[i].Gap)
+		}
+	}
+}
+
+func TestBuildResultsFiltered(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, Competitors: []int{1, 4, 9}}
+
+	start, _ := parseTime("[10:00:00.000]")
+	finisher := func(id int, totalTime time.Duration) *Competitor {
+		return &Competitor{
+			ID:              id,
+			Status:          "Finished",
+			ActualStartTime: start,
+			FinishTime:      start.Add(totalTime),
+			LapTimes:        []time.Duration{totalTime},
+		}
+	}
+	competitors := map[int]*Competitor{
+		1: finisher(1, 12*time.Minute),
+		2: finisher(2, 11*time.Minute),
+		3: finisher(3, 11*time.Minute+30*time.Second),
+		4: {ID: 4, Status: "NotFinished"},
+	}
+
+	rows := BuildResults(competitors, config)
+	if len(rows) != 2 {
+		t.Fatalf("Expected only the listed competitors, got %d rows", len(rows))
+	}
+	if rows[0].CompetitorID != 1 || rows[0].Place != 3 || rows[0].Gap != time.Minute {
+		t.Errorf("Expected competitor 1 in place 3 a minute behind, got %d in place %d with gap %v",
+			rows[0].CompetitorID, rows[0].Place, rows[0].Gap)
+	}
+	if rows[1].CompetitorID != 4 {
+		t.Errorf("Expected competitor 4 last, got %d", rows[1].CompetitorID)
+	}
+}
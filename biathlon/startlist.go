@@ -0,0 +1,61 @@
+package biathlon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// StartListEntry is a competitor known before the race.
+type StartListEntry struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Nation string `json:"nation,omitempty"`
+	Bib    int    `json:"bib,omitempty"`
+}
+
+// ParseStartList reads a start list: a JSON array of {id, name, nation, bib}
+// objects. Every competitor may be listed only once.
+func ParseStartList(r io.Reader) ([]StartListEntry, error) {
+	var entries []StartListEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	for _, entry := range entries {
+		if seen[entry.ID] {
+			return nil, fmt.Errorf("competitor(%d) is listed more than once", entry.ID)
+		}
+		seen[entry.ID] = true
+	}
+
+	return entries, nil
+}
+
+// ParseStartDeltaOverrides reads a JSON object mapping competitor IDs to
+// start windows in the "HH:MM:SS" form of StartDelta, for competitors whose
+// window differs from the configured one.
+func ParseStartDeltaOverrides(r io.Reader) (map[int]time.Duration, error) {
+	var raw map[string]string
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[int]time.Duration, len(raw))
+	for key, value := range raw {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid competitor ID %q", key)
+		}
+		delta, err := parseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start delta %q for competitor(%d): %v", value, id, err)
+		}
+		overrides[id] = delta
+	}
+
+	return overrides, nil
+}
@@ -0,0 +1,151 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStartList(t *testing.T) {
+	input := `[
+		{"id": 1, "name": "Anna Svensson", "nation": "SWE", "bib": 7},
+		{"id": 2, "name": "Ole Einar"}
+	]`
+
+	entries, err := ParseStartList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []StartListEntry{
+		{ID: 1, Name: "Anna Svensson", Nation: "SWE", Bib: 7},
+		{ID: 2, Name: "Ole Einar"},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, entries)
+	}
+
+	invalid := []string{
+		`{"id": 1}`,
+		`[{"id": "one"}]`,
+		`[{"id": 1, "name": "Anna Svensson"}, {"id": 1, "name": "Ole Einar"}]`,
+	}
+
+	for _, input := range invalid {
+		if _, err := ParseStartList(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected error for input %q, but got none", input)
+		}
+	}
+}
+
+func TestProcessEventsWithStartList(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+	startList := []StartListEntry{
+		{ID: 1, Name: "Anna Svensson", Nation: "SWE", Bib: 7},
+		{ID: 2, Nation: "NOR"},
+	}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:31:00.000] 1 3",
+		"[09:59:00.000] 2 1 10:00:00.000",
+		"[10:00:00.000] 4 1",
+	})
+
+	var out bytes.Buffer
+	p := NewProcessor(config, WithMode(Strict), WithStartList(startList),
+		WithNames(map[int]string{2: "Ole Einar"}), WithLogger(narrationLogger(&out)))
+
+	if competitor := p.Results()[2]; competitor == nil || competitor.Name != "Ole Einar" || competitor.Status != "NotStarted" {
+		t.Fatalf("Expected competitor 2 to be known before the race, got %+v", competitor)
+	}
+
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	competitors := p.Finalize()
+
+	first := competitors[1]
+	if first.Status != "Started" || first.Nation != "SWE" || first.Bib != 7 || !first.RegisteredTime.Equal(events[0].Time) {
+		t.Errorf("Expected competitor 1 confirmed and started, got %+v", first)
+	}
+	if !strings.Contains(out.String(), "[09:30:00.000] The competitor Anna Svensson(1) confirmed the registration\n") {
+		t.Errorf("Expected the confirmation to be logged, got:\n%s", out.String())
+	}
+
+	if competitors[2].Status != "NotStarted" || !competitors[2].RegisteredTime.IsZero() {
+		t.Errorf("Expected competitor 2 still unconfirmed, got %+v", competitors[2])
+	}
+
+	expected := "[09:31:00.000] event 1 for competitor(3): competitor(3) is not on the start list"
+	if warnings := p.Warnings(); len(warnings) != 1 || warnings[0].String() != expected {
+		t.Errorf("Expected a warning about competitor 3, got %v", warnings)
+	}
+	if competitors[3] == nil {
+		t.Errorf("Expected competitor 3 to be registered anyway")
+	}
+
+	entries := BuildReportEntries(competitors, config)
+	if len(entries) != 3 || entries[0].CompetitorID != 1 || entries[0].Nation != "SWE" || entries[0].Bib != 7 {
+		t.Errorf("Expected the start list details in the report, got %+v", entries)
+	}
+}
+
+func TestParseStartDeltaOverrides(t *testing.T) {
+	overrides, err := ParseStartDeltaOverrides(strings.NewReader(`{"1": "00:01:00", "3": "00:00:10.5"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[int]time.Duration{1: time.Minute, 3: 10*time.Second + 500*time.Millisecond}
+	if !reflect.DeepEqual(overrides, expected) {
+		t.Errorf("Expected %v, got %v", expected, overrides)
+	}
+
+	invalid := []string{
+		`["00:01:00"]`,
+		`{"one": "00:01:00"}`,
+		`{"1": "1 minute"}`,
+		`{"1": 60}`,
+	}
+
+	for _, input := range invalid {
+		if _, err := ParseStartDeltaOverrides(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected error for input %q, but got none", input)
+		}
+	}
+}
+
+func TestProcessEventsWithStartDeltaOverrides(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, StartDelta: "00:00:30"}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:30:02.000] 1 3",
+		"[09:59:00.000] 2 1 10:00:00.000",
+		"[09:59:00.000] 2 2 10:00:00.000",
+		"[09:59:00.000] 2 3 10:00:00.000",
+		"[10:00:45.000] 4 1",
+		"[10:00:45.000] 4 2",
+		"[10:05:00.000] 5 1 1",
+	})
+
+	// Competitor 3 never starts and is only disqualified once their own
+	// window has passed
+	p := NewProcessor(config, WithStartDeltaOverrides(map[int]time.Duration{1: time.Minute, 3: 10 * time.Minute}))
+	if err := p.AddEvents(context.Background(), events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	competitors := p.Finalize()
+
+	expected := map[int]string{1: "Started", 2: "Disqualified", 3: "NotStarted"}
+	for id, status := range expected {
+		if competitors[id].Status != status {
+			t.Errorf("Expected competitor %d to be %s, got %s", id, status, competitors[id].Status)
+		}
+	}
+}
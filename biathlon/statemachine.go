@@ -0,0 +1,181 @@
+package biathlon
+
+import (
+	"fmt"
+	"slices"
+)
+
+// CompetitorState is a competitor's position in the race as implied by the
+// sequence of events seen for them.
+type CompetitorState int
+
+const (
+	StateUnregistered CompetitorState = iota
+	StateRegistered
+	StateStartSet
+	StateOnStartLine
+	StateOnCourse
+	StateOnRange
+	StateInPenalty
+	StateFinished
+	StateNotFinished
+
+	// stateUnchanged is the target of transitions that leave the competitor
+	// where they are. No competitor is ever in it.
+	stateUnchanged CompetitorState = -1
+)
+
+var stateNames = map[CompetitorState]string{
+	StateUnregistered: "Unregistered",
+	StateRegistered:   "Registered",
+	StateStartSet:     "StartSet",
+	StateOnStartLine:  "OnStartLine",
+	StateOnCourse:     "OnCourse",
+	StateOnRange:      "OnRange",
+	StateInPenalty:    "InPenalty",
+	StateFinished:     "Finished",
+	StateNotFinished:  "NotFinished",
+}
+
+func (s CompetitorState) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("CompetitorState(%d)", int(s))
+}
+
+// transitions lists the states each incoming event may be applied in and the
+// state it leads to. Event 10 on the last lap leads to StateFinished instead.
+// A course re-measurement (event 16) concerns no competitor and is always
+// allowed, as is the start gun (event 17 without a competitor), which puts
+// everyone waiting to start on the course.
+var transitions = map[int]struct {
+	from []CompetitorState
+	to   CompetitorState
+}{
+	1:  {[]CompetitorState{StateUnregistered}, StateRegistered},
+	2:  {[]CompetitorState{StateRegistered, StateStartSet}, StateStartSet},
+	3:  {[]CompetitorState{StateRegistered, StateStartSet}, StateOnStartLine},
+	4:  {[]CompetitorState{StateRegistered, StateStartSet, StateOnStartLine}, StateOnCourse},
+	5:  {[]CompetitorState{StateOnCourse}, StateOnRange},
+	6:  {[]CompetitorState{StateOnRange}, StateOnRange},
+	7:  {[]CompetitorState{StateOnRange}, StateOnCourse},
+	8:  {[]CompetitorState{StateOnCourse}, StateInPenalty},
+	9:  {[]CompetitorState{StateInPenalty}, StateOnCourse},
+	10: {[]CompetitorState{StateOnCourse}, StateOnCourse},
+	11: {[]CompetitorState{StateRegistered, StateStartSet, StateOnStartLine, StateOnCourse, StateOnRange, StateInPenalty}, StateNotFinished},
+	12: {[]CompetitorState{StateNotFinished}, StateOnCourse},
+	13: {[]CompetitorState{StateOnCourse}, StateOnCourse},
+	14: {[]CompetitorState{StateRegistered, StateStartSet, StateOnStartLine, StateOnCourse, StateOnRange, StateInPenalty, StateFinished, StateNotFinished}, stateUnchanged},
+	15: {[]CompetitorState{StateOnRange}, StateOnRange},
+	17: {[]CompetitorState{StateRegistered, StateStartSet, StateOnStartLine}, StateOnCourse},
+	18: {[]CompetitorState{StateRegistered, StateStartSet, StateOnStartLine, StateOnCourse}, stateUnchanged},
+	19: {[]CompetitorState{StateOnCourse, StateFinished}, stateUnchanged},
+}
+
+// isRaceWideEvent reports whether event concerns the race rather than one
+// competitor.
+func isRaceWideEvent(event EventLog) bool {
+	return event.EventID == 16 || event.EventID == 17 && event.CompetitorID == 0
+}
+
+// stateMachine tracks the state of every competitor through a sequence of events.
+type stateMachine struct {
+	laps   int
+	states map[int]CompetitorState
+	lapsOf map[int]int
+}
+
+func newStateMachine(config Configuration) *stateMachine {
+	return &stateMachine{
+		laps:   config.Laps,
+		states: make(map[int]CompetitorState),
+		lapsOf: make(map[int]int),
+	}
+}
+
+// check reports whether event is a legal transition without applying it.
+func (m *stateMachine) check(event EventLog) error {
+	if isRaceWideEvent(event) {
+		return nil
+	}
+
+	transition, ok := transitions[event.EventID]
+	if !ok {
+		return fmt.Errorf("unknown event ID %d", event.EventID)
+	}
+
+	state := m.states[event.CompetitorID]
+	for _, from := range transition.from {
+		if state == from {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("event %d is not allowed in state %s", event.EventID, state)
+}
+
+// apply moves the competitor of event to its next state. The event must have
+// passed check.
+func (m *stateMachine) apply(event EventLog) {
+	transition, ok := transitions[event.EventID]
+	if !ok || transition.to == stateUnchanged {
+		return
+	}
+	if isRaceWideEvent(event) {
+		for id, state := range m.states {
+			if slices.Contains(transition.from, state) {
+				m.states[id] = transition.to
+			}
+		}
+		return
+	}
+
+	next := transition.to
+	if event.EventID == 10 {
+		m.lapsOf[event.CompetitorID]++
+		if m.lapsOf[event.CompetitorID] >= m.laps {
+			next = StateFinished
+		}
+	}
+
+	m.states[event.CompetitorID] = next
+}
+
+// ValidationError describes an event that is illegal for its competitor's state.
+type ValidationError struct {
+	Line  int // 1-based position of the event in the validated slice
+	Event EventLog
+	State CompetitorState
+	Err   error
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("line %d: [%s] %d %d: %v",
+		e.Line, formatTime(e.Event.Time), e.Event.EventID, e.Event.CompetitorID, e.Err)
+}
+
+// ValidateEvents runs the events through the per-competitor state machine
+// (Registered → StartSet → OnStartLine → OnCourse ↔ {OnRange, InPenalty} →
+// Finished/NotFinished) and reports every illegal transition. Illegal events
+// are skipped, so one bad event does not cascade into many reports.
+func ValidateEvents(events []EventLog, config Configuration) []ValidationError {
+	m := newStateMachine(config)
+
+	var validationErrs []ValidationError
+	for i, event := range events {
+		if err := m.check(event); err != nil {
+			validationErrs = append(validationErrs, ValidationError{
+				Line:  i + 1,
+				Event: event,
+				State: m.states[event.CompetitorID],
+				Err:   err,
+			})
+			continue
+		}
+		m.apply(event)
+	}
+
+	return validationErrs
+}
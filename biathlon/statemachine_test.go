@@ -0,0 +1,144 @@
+package biathlon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestValidateEvents(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:50:00.000] 10 1",
+		"[10:00:00.000] 4 1",
+		"[10:00:30.000] 4 2",
+		"[10:05:00.000] 6 2 1",
+		"[10:05:01.000] 7 2",
+		"[10:06:00.000] 5 1 1",
+		"[10:06:01.000] 6 1 1",
+		"[10:06:02.000] 7 1",
+		"[10:06:10.000] 9 1",
+		"[10:12:00.000] 10 1",
+		"[10:13:00.000] 5 1 2",
+	})
+
+	validationErrs := ValidateEvents(events, config)
+
+	expected := []struct {
+		line  int
+		state CompetitorState
+	}{
+		{3, StateRegistered},
+		{6, StateOnCourse},
+		{7, StateOnCourse},
+		{11, StateOnCourse},
+		{13, StateFinished},
+	}
+
+	if len(validationErrs) != len(expected) {
+		t.Fatalf("Expected %d validation errors, got %d: %v", len(expected), len(validationErrs), validationErrs)
+	}
+
+	for i, e := range expected {
+		if validationErrs[i].Line != e.line || validationErrs[i].State != e.state {
+			t.Errorf("Error %d: expected line %d in state %s, got line %d in state %s",
+				i, e.line, e.state, validationErrs[i].Line, validationErrs[i].State)
+		}
+	}
+
+	if msg := validationErrs[0].Error(); msg != "line 3: [09:50:00.000] 10 1: event 10 is not allowed in state Registered" {
+		t.Errorf("Unexpected error message: %s", msg)
+	}
+}
+
+func TestValidateEventsStartGun(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:30:02.000] 1 3",
+		"[09:55:00.000] 3 2",
+		"[09:56:00.000] 11 3 Ill",
+		"[10:00:00.000] 17",
+		"[10:12:00.000] 10 1",
+		"[10:12:30.000] 10 2",
+		"[10:13:00.000] 4 3",
+	})
+
+	validationErrs := ValidateEvents(events, config)
+	if len(validationErrs) != 1 || validationErrs[0].Line != 9 || validationErrs[0].State != StateNotFinished {
+		t.Errorf("Expected only the start of competitor 3 on line 9 to be rejected, got %v", validationErrs)
+	}
+}
+
+func TestValidateEventsSample(t *testing.T) {
+	eventsFile, err := os.Open("../sunny_5_skiers/events")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sample events: %v", err)
+	}
+	defer eventsFile.Close()
+
+	events, err := ReadEvents(context.Background(), eventsFile)
+	if err != nil {
+		t.Fatalf("Unexpected error reading sample events: %v", err)
+	}
+
+	config := Configuration{Laps: 2, LapLen: 3500, PenaltyLen: 150, FiringLines: 2}
+	if validationErrs := ValidateEvents(events, config); len(validationErrs) != 0 {
+		t.Errorf("Expected sample events to be valid, got %v", validationErrs)
+	}
+}
+
+func TestWithStateValidation(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:05:00.000] 6 1 1",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict))
+	if err != nil || competitors[1].Hits != 1 {
+		t.Fatalf("Without state validation the hit should be counted, got %v, %d hits", err, competitors[1].Hits)
+	}
+
+	competitors, _, _, err = ProcessEvents(context.Background(), events, config, WithMode(Strict), WithStateValidation(true))
+
+	var eventErr *EventError
+	if !errors.As(err, &eventErr) || eventErr.Event.EventID != 6 {
+		t.Fatalf("Expected the hit without firing range to be rejected, got %v", err)
+	}
+
+	if competitors[1].Hits != 0 {
+		t.Errorf("Rejected hit should not be counted, got %d hits", competitors[1].Hits)
+	}
+}
+
+func TestStateMachineUnchanged(t *testing.T) {
+	m := newStateMachine(Configuration{Laps: 1})
+
+	for _, line := range []string{
+		"[09:30:00.000] 1 1",
+		"[09:59:00.000] 18 1 1",
+		"[10:00:00.000] 4 1",
+		"[10:01:00.000] 14 1 4 09:59:59.000 typo",
+		"[10:10:00.000] 19 1",
+	} {
+		event := parseEvents(t, []string{line})[0]
+		before := m.states[event.CompetitorID]
+		if err := m.check(event); err != nil {
+			t.Fatalf("%s: unexpected error: %v", line, err)
+		}
+		m.apply(event)
+
+		if event.EventID >= 14 && m.states[event.CompetitorID] != before {
+			t.Errorf("%s: expected the state to stay %s, got %s", line, before, m.states[event.CompetitorID])
+		}
+	}
+}
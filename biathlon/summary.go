@@ -0,0 +1,136 @@
+package biathlon
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// RaceSummary holds aggregate statistics over all competitors of a race.
+type RaceSummary struct {
+	Starters     int
+	Finishers    int
+	NotFinished  int
+	Withdrew     int
+	Disqualified int
+
+	FastestLap             time.Duration
+	FastestLapCompetitorID int // zero if nobody completed a lap
+
+	BestAccuracy             float64 // hits/shots of the best shooter
+	BestAccuracyCompetitorID int     // zero if nobody fired a shot
+
+	AverageFinishTime time.Duration // zero if nobody finished
+
+	ShotsPerRange map[int]int // total shots fired at each firing range
+}
+
+// BuildSummary aggregates the race statistics. Ties are resolved in favour of
+// the lower competitor ID.
+func BuildSummary(competitors map[int]*Competitor, config Configuration) RaceSummary {
+	summary := RaceSummary{ShotsPerRange: make(map[int]int)}
+
+	ids := make([]int, 0, len(competitors))
+	for id := range competitors {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		competitor := competitors[id]
+
+		if !competitor.ActualStartTime.IsZero() {
+			summary.Starters++
+		}
+
+		switch competitor.Status {
+		case "NotFinished":
+			summary.NotFinished++
+		case "Withdrew":
+			summary.Withdrew++
+		case "Disqualified":
+			summary.Disqualified++
+		}
+
+		for _, lapTime := range competitor.LapTimes {
+			if summary.FastestLapCompetitorID == 0 || lapTime < summary.FastestLap {
+				summary.FastestLap = lapTime
+				summary.FastestLapCompetitorID = id
+			}
+		}
+
+		if competitor.Shots > 0 {
+			accuracy := competitor.ShotAccuracy()
+			if summary.BestAccuracyCompetitorID == 0 || accuracy > summary.BestAccuracy {
+				summary.BestAccuracy = accuracy
+				summary.BestAccuracyCompetitorID = id
+			}
+		}
+
+		for firingRange, shots := range competitor.PerRangeShots {
+			summary.ShotsPerRange[firingRange] += shots
+		}
+	}
+
+	var totalFinishTime time.Duration
+	for _, row := range BuildResults(competitors, config) {
+		if row.Status == "Finished" {
+			summary.Finishers++
+			totalFinishTime += row.TotalTime
+		}
+	}
+	if summary.Finishers > 0 {
+		summary.AverageFinishTime = totalFinishTime / time.Duration(summary.Finishers)
+	}
+
+	return summary
+}
+
+// WriteSummary renders the race summary as text to w. It covers the whole
+// field, whatever config.Competitors lists.
+func WriteSummary(w io.Writer, competitors map[int]*Competitor, config Configuration) error {
+	summary := BuildSummary(competitors, config)
+
+	lines := []string{
+		"\nRace Summary:",
+		fmt.Sprintf("Starters: %d", summary.Starters),
+		fmt.Sprintf("Finishers: %d", summary.Finishers),
+		fmt.Sprintf("Not finished: %d", summary.NotFinished),
+		fmt.Sprintf("Withdrew: %d", summary.Withdrew),
+		fmt.Sprintf("Disqualified: %d", summary.Disqualified),
+	}
+
+	if summary.FastestLapCompetitorID != 0 {
+		lines = append(lines, fmt.Sprintf("Fastest lap: %s by %s",
+			formatDuration(summary.FastestLap), competitors[summary.FastestLapCompetitorID].Label()))
+	}
+
+	if summary.BestAccuracyCompetitorID != 0 {
+		best := competitors[summary.BestAccuracyCompetitorID]
+		lines = append(lines, fmt.Sprintf("Best shooting: %d/%d (%s) by %s",
+			best.Hits, best.Shots, best.ShotAccuracyPercent(), best.Label()))
+	}
+
+	if summary.Finishers > 0 {
+		lines = append(lines, fmt.Sprintf("Average finish time: %s", formatDuration(summary.AverageFinishTime)))
+	}
+
+	ranges := make([]int, 0, len(summary.ShotsPerRange))
+	for firingRange := range summary.ShotsPerRange {
+		ranges = append(ranges, firingRange)
+	}
+	sort.Ints(ranges)
+
+	for _, firingRange := range ranges {
+		lines = append(lines, fmt.Sprintf("Shots at firing range %d: %d", firingRange, summary.ShotsPerRange[firingRange]))
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
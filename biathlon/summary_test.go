@@ -0,0 +1,83 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBuildSummary(t *testing.T) {
+	config := Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, StartDelta: "00:01:30"}
+
+	events := parseEvents(t, []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[09:30:02.000] 1 3",
+		"[09:30:03.000] 1 4",
+		"[09:50:00.000] 2 4 10:00:00.000",
+		"[10:00:00.000] 4 1",
+		"[10:00:30.000] 4 2",
+		"[10:01:00.000] 4 3",
+		"[10:05:00.000] 5 1 1",
+		"[10:05:01.000] 6 1 1",
+		"[10:05:02.000] 6 1 2",
+		"[10:05:03.000] 7 1",
+		"[10:06:00.000] 5 2 2",
+		"[10:06:01.000] 6 2 1",
+		"[10:06:03.000] 7 2",
+		"[10:10:00.000] 11 3 Lost in the forest",
+		"[10:12:00.000] 10 1",
+		"[10:14:30.000] 10 2",
+	})
+
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config, WithMode(Strict),
+		WithClock(func() time.Time { return events[len(events)-1].Time }))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	summary := BuildSummary(competitors, config)
+
+	if summary.Starters != 3 || summary.Finishers != 2 || summary.NotFinished != 1 || summary.Disqualified != 1 {
+		t.Errorf("Unexpected counts: %+v", summary)
+	}
+
+	if summary.FastestLapCompetitorID != 1 || summary.FastestLap != 12*time.Minute {
+		t.Errorf("Expected fastest lap 12m by competitor 1, got %v by %d", summary.FastestLap, summary.FastestLapCompetitorID)
+	}
+
+	if summary.BestAccuracyCompetitorID != 1 || summary.BestAccuracy != 0.4 {
+		t.Errorf("Expected best accuracy 0.4 by competitor 1, got %v by %d", summary.BestAccuracy, summary.BestAccuracyCompetitorID)
+	}
+
+	if summary.AverageFinishTime != 13*time.Minute {
+		t.Errorf("Expected average finish time 13m, got %v", summary.AverageFinishTime)
+	}
+
+	if len(summary.ShotsPerRange) != 2 || summary.ShotsPerRange[1] != 5 || summary.ShotsPerRange[2] != 5 {
+		t.Errorf("Unexpected shots per range: %v", summary.ShotsPerRange)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSummary(&buf, competitors, config); err != nil {
+		t.Fatalf("Unexpected error writing summary: %v", err)
+	}
+
+	expected := `
+Race Summary:
+Starters: 3
+Finishers: 2
+Not finished: 1
+Withdrew: 0
+Disqualified: 1
+Fastest lap: 00:12:00.000 by competitor(1)
+Best shooting: 2/5 (40.0%) by competitor(1)
+Average finish time: 00:13:00.000
+Shots at firing range 1: 5
+Shots at firing range 2: 5
+`
+	if buf.String() != expected {
+		t.Errorf("Expected summary:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
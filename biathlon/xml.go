@@ -0,0 +1,126 @@
+package biathlon
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// XMLReport is the XML form of the final results, for federation upload
+// tools. The schema is stable: a Race root with the course as attributes and
+// one Competitor per competitor in standings order, e.g.
+//
+//	<Race laps="2" lapLen="3500" penaltyLen="150" firingLines="2" start="09:30:00.000" startDelta="00:00:30">
+//	  <Competitor id="2" place="1">
+//	    <Status>Finished</Status>
+//	    <TotalTime>00:25:18.356</TotalTime>
+//	    <Lap number="1" time="00:12:38.243" speed="4.615934469556593"></Lap>
+//	    <Penalty time="00:01:40.000" speed="3"></Penalty>
+//	    <Shooting hits="8" shots="10"></Shooting>
+//	  </Competitor>
+//	</Race>
+//
+// Speeds are in m/s regardless of the configured speed unit. The Race also
+// has a generator attribute naming the program that wrote it, if known.
+type XMLReport struct {
+	XMLName     xml.Name        `xml:"Race"`
+	Generator   string          `xml:"generator,attr,omitempty"` // Configuration.Generator
+	Laps        int             `xml:"laps,attr"`
+	LapLen      int             `xml:"lapLen,attr"`
+	PenaltyLen  int             `xml:"penaltyLen,attr"`
+	FiringLines int             `xml:"firingLines,attr"`
+	Start       string          `xml:"start,attr"`
+	StartDelta  string          `xml:"startDelta,attr"`
+	Competitors []XMLCompetitor `xml:"Competitor"`
+}
+
+// XMLCompetitor is the XML form of a ResultRow. Place and TotalTime are only
+// set for finishers, Reason for competitors who did not finish or were
+// disqualified.
+type XMLCompetitor struct {
+	ID        int         `xml:"id,attr"`
+	Name      string      `xml:"name,attr,omitempty"`
+	Nation    string      `xml:"nation,attr,omitempty"`
+	Place     int         `xml:"place,attr,omitempty"`
+	Status    string      `xml:"Status"`
+	Reason    string      `xml:"Reason,omitempty"`
+	TotalTime string      `xml:"TotalTime,omitempty"`
+	Laps      []XMLLap    `xml:"Lap"`
+	Penalty   *XMLPenalty `xml:"Penalty"`
+	Shooting  XMLShooting `xml:"Shooting"`
+}
+
+// XMLLap is a completed lap, numbered from 1.
+type XMLLap struct {
+	Number int     `xml:"number,attr"`
+	Time   string  `xml:"time,attr"`
+	Speed  float64 `xml:"speed,attr"`
+}
+
+// XMLPenalty is the time and speed on the penalty loops, if any were run.
+type XMLPenalty struct {
+	Time  string  `xml:"time,attr"`
+	Speed float64 `xml:"speed,attr"`
+}
+
+// XMLShooting is the overall shooting result.
+type XMLShooting struct {
+	Hits  int `xml:"hits,attr"`
+	Shots int `xml:"shots,attr"`
+}
+
+// newXMLReport converts the standings to their XML form.
+func newXMLReport(rows []ResultRow, config Configuration) XMLReport {
+	report := XMLReport{
+		Generator:   config.Generator,
+		Laps:        config.Laps,
+		LapLen:      config.LapLen,
+		PenaltyLen:  config.PenaltyLen,
+		FiringLines: config.FiringLines,
+		Start:       config.Start,
+		StartDelta:  config.StartDelta,
+	}
+
+	for _, row := range rows {
+		competitor := XMLCompetitor{
+			ID:       row.CompetitorID,
+			Name:     row.Name,
+			Nation:   row.Nation,
+			Place:    row.Place,
+			Status:   row.Status,
+			Reason:   row.DNFReason,
+			Shooting: XMLShooting{Hits: row.Hits, Shots: row.Shots},
+		}
+		if row.Status == "Disqualified" {
+			competitor.Reason = row.DisqualificationReason
+		}
+		if row.Status == "Finished" {
+			competitor.TotalTime = formatDuration(row.TotalTime)
+		}
+		for i, lap := range row.Laps {
+			competitor.Laps = append(competitor.Laps, XMLLap{Number: i + 1, Time: lap.Time, Speed: lap.Speed})
+		}
+		if row.Penalty.Time != "" {
+			competitor.Penalty = &XMLPenalty{Time: row.Penalty.Time, Speed: row.Penalty.Speed}
+		}
+		report.Competitors = append(report.Competitors, competitor)
+	}
+
+	return report
+}
+
+// writeXMLReport writes the standings as an XMLReport document. The element
+// order is fixed by the structs, so the same results give the same file.
+func writeXMLReport(w io.Writer, rows []ResultRow, config Configuration) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(newXMLReport(rows, config)); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,62 @@
+package biathlon
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteReportXMLRoundTrip(t *testing.T) {
+	config, _, err := LoadConfiguration("../sunny_5_skiers/config.json", "")
+	if err != nil {
+		t.Fatalf("Unexpected error loading sample configuration: %v", err)
+	}
+	eventsFile, err := os.Open("../sunny_5_skiers/events")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sample events: %v", err)
+	}
+	defer eventsFile.Close()
+
+	events, err := ReadEvents(context.Background(), eventsFile)
+	if err != nil {
+		t.Fatalf("Unexpected error reading sample events: %v", err)
+	}
+	competitors, _, _, err := ProcessEvents(context.Background(), events, config)
+	if err != nil {
+		t.Fatalf("Unexpected error processing sample events: %v", err)
+	}
+
+	// Add competitors who did not finish to cover the optional elements
+	competitors[6] = &Competitor{ID: 6, Name: "Anna & Ole", Nation: "NOR", Status: "NotFinished",
+		DNFReason: "Lost in the forest", LapTimes: []time.Duration{13 * time.Minute}, Hits: 3, Shots: 5}
+	competitors[7] = &Competitor{ID: 7, Status: "Disqualified", DisqualificationReason: "did not start within allowed window"}
+
+	var first, second bytes.Buffer
+	if err := WriteReport(&first, competitors, config, FormatXML); err != nil {
+		t.Fatalf("Unexpected error writing XML report: %v", err)
+	}
+	if err := WriteReport(&second, competitors, config, FormatXML); err != nil {
+		t.Fatalf("Unexpected error writing XML report: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("Expected identical reports for the same results")
+	}
+
+	var report XMLReport
+	if err := xml.Unmarshal(first.Bytes(), &report); err != nil {
+		t.Fatalf("Unexpected error unmarshaling XML report: %v", err)
+	}
+	expected := newXMLReport(BuildResults(competitors, config), config)
+	expected.XMLName = xml.Name{Local: "Race"}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Expected the XML report to round-trip to\n%+v\ngot\n%+v", expected, report)
+	}
+
+	if len(report.Competitors) != 7 || report.Competitors[5].Reason != "Lost in the forest" || report.Competitors[5].Penalty != nil {
+		t.Errorf("Unexpected competitor who did not finish %+v", report.Competitors[5])
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// options holds the command line of the program.
+type options struct {
+	configPath  string
+	eventsPaths pathList // ["-"] is stdin; none in stream mode means stdin
+
+	configFormat     string
+	format           string
+	noColor          bool
+	templatePath     string
+	outPath          string
+	logPath          string
+	quiet            bool
+	outEventsPath    string
+	appendOutgoing   bool
+	strict           bool
+	competitorsPath  string
+	competitors      idList
+	startDeltasPath  string
+	namesPath        string
+	logLevel         string
+	logFormat        string
+	summary          bool
+	dryRun           bool
+	validate         bool
+	leaderboardPath  string
+	replay           bool
+	speed            float64
+	listen           string
+	metricsAddr      string
+	readTimeout      time.Duration
+	verbose          bool
+	stream           bool
+	watch            bool
+	follow           bool
+	refresh          time.Duration
+	pollInterval     time.Duration
+	pursuitSource    string
+	raceDate         string
+	speedUnit        string
+	speedDecimals    *int // nil unless -speed-decimals is given
+	nationScoreCount int
+	sessions         int
+	version          bool
+	versionHeader    bool
+}
+
+// parseArgs parses the command line without the program name. The
+// configuration and events may be given with -config and -events or, as
+// before, as the first two positional arguments. Without an events file the
+// events are read from stdin if it is piped. Errors are reported to stderr
+// together with the usage; -h returns flag.ErrHelp.
+func parseArgs(args []string, stderr io.Writer, stdinPiped bool) (options, error) {
+	var opts options
+
+	fs := flag.NewFlagSet("biathlon", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: biathlon [flags] [-config <file>] -events <file|-> [-events <file>...]")
+		fmt.Fprintln(stderr, "       biathlon [flags] <config> [<events>...]")
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&opts.configPath, "config", "", "race configuration file (JSON, YAML or TOML); without it the RACE_* environment variables configure the race")
+	fs.Var(&opts.eventsPaths, "events", "events file, or - for stdin; repeat it or use a glob to merge several files, e.g. one per timing station")
+	fs.StringVar(&opts.configFormat, "config-format", "", "configuration format: json, yaml or toml (default: detect from the file extension)")
+	fs.StringVar(&opts.format, "format", "text", "final report format: text, color, json, json-race (json with the generator and race-wide state), csv, markdown, html or xml (text is colored on a terminal)")
+	fs.BoolVar(&opts.noColor, "no-color", false, "never highlight the text report with ANSI colors")
+	fs.StringVar(&opts.templatePath, "template", "", "html/template file to render the -format html report with instead of the built-in one")
+	fs.StringVar(&opts.outPath, "out", "", "write the final report to this file instead of stdout")
+	fs.StringVar(&opts.logPath, "log", "", "write the event narration to this file instead of stderr")
+	fs.BoolVar(&opts.quiet, "quiet", false, "do not narrate the events, print only the final report")
+	fs.StringVar(&opts.outEventsPath, "out-events", "", "write outgoing events to this file instead of the narration")
+	fs.BoolVar(&opts.appendOutgoing, "append-outgoing", false, "also append outgoing events to the events file as an audit log, stamped with the wall clock time")
+	fs.BoolVar(&opts.strict, "strict", false, "stop at the first invalid event instead of skipping it")
+	fs.StringVar(&opts.competitorsPath, "competitors-file", "", "JSON start list of {id, name, nation, bib} objects registering competitors in advance")
+	fs.Var(&opts.competitors, "competitors", "only narrate and report these comma-separated competitor IDs, e.g. 7,12; every event is still processed, and the nation, relay and summary sections cover the whole field")
+	fs.StringVar(&opts.startDeltasPath, "start-delta-overrides", "", "JSON object mapping competitor IDs to their own start window, e.g. {\"3\": \"00:01:00\"}")
+	fs.StringVar(&opts.namesPath, "names", "", "tab-separated file mapping competitor IDs to names")
+	fs.StringVar(&opts.logLevel, "log-level", "info", "commentary log level: debug, info, warn or error")
+	fs.StringVar(&opts.logFormat, "log-format", "text", "commentary log format: text or json")
+	fs.BoolVar(&opts.summary, "summary", false, "print race summary statistics after the final report")
+	fs.BoolVar(&opts.validate, "validate", false, "only check the configuration and every event line, print the problems with their line numbers and a summary")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "only check the configuration and events and print a summary of the problems found")
+	fs.StringVar(&opts.leaderboardPath, "leaderboard-out", "", "write live standings as newline-delimited JSON to this file after every lap")
+	fs.BoolVar(&opts.replay, "replay", false, "replay the events with their real gaps, as if the race were live")
+	fs.Float64Var(&opts.speed, "speed", 1.0, "replay speed factor; 0 replays without waiting")
+	fs.StringVar(&opts.listen, "listen", "", "accept one TCP connection on this host:port and read the events from it")
+	fs.StringVar(&opts.metricsAddr, "metrics-addr", "", "serve Prometheus metrics of the race at http://host:port/metrics while it is processed")
+	fs.DurationVar(&opts.readTimeout, "read-timeout", 30*time.Second, "end the input from -listen after this long without data (0 waits forever)")
+	fs.BoolVar(&opts.verbose, "verbose", false, "report which configuration fields were taken from the defaults and narrate the processor's decisions")
+	fs.BoolVar(&opts.verbose, "v", false, "shorthand for -verbose")
+	fs.BoolVar(&opts.stream, "stream", false, "process events line by line as they arrive on stdin (or the given events path)")
+	fs.BoolVar(&opts.watch, "watch", false, "keep reading the events file as it grows until the race-concluded event 99")
+	fs.BoolVar(&opts.follow, "follow", false, "-watch printing provisional standings as the race goes, with the final report when interrupted")
+	fs.DurationVar(&opts.refresh, "refresh", 10*time.Second, "how often -follow prints the provisional standings, also printed when Enter is pressed (0 only then)")
+	fs.DurationVar(&opts.pollInterval, "poll-interval", 100*time.Millisecond, "how often -watch and -follow check the events file for new lines")
+	fs.StringVar(&opts.pursuitSource, "pursuit-source", "", "start competitors as far behind the configured start as they finished this previous race's JSON results")
+	fs.StringVar(&opts.raceDate, "race-date", "", "date of the first event as YYYY-MM-DD, later events roll over to the following days")
+	fs.StringVar(&opts.speedUnit, "speed-unit", "", "report speeds in m/s, km/h or min/km (default: the configuration's speedUnit, or m/s)")
+	fs.Func("speed-decimals", "report speeds with this many decimals (default: the configuration's speedDecimals, or 3)", func(value string) error {
+		decimals, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		opts.speedDecimals = &decimals
+		return nil
+	})
+	fs.IntVar(&opts.nationScoreCount, "nation-score-count", 0, "score nations by the combined time of this many best finishers (default: the configuration's nationScoreCount, or 3)")
+	fs.IntVar(&opts.sessions, "sessions", 1, "run this many races back to back, reloading the configuration and reading the events files again before each")
+	fs.BoolVar(&opts.version, "version", false, "print the version, commit and build date and exit")
+	fs.BoolVar(&opts.versionHeader, "version-header", false, "start the text report with the version that wrote it, as the json-race, CSV and XML reports always record")
+
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+
+	usageError := func(format string, a ...any) (options, error) {
+		err := fmt.Errorf(format, a...)
+		fmt.Fprintln(stderr, err)
+		fs.Usage()
+		return options{}, err
+	}
+
+	// The version needs no configuration or events
+	if opts.version {
+		return opts, nil
+	}
+
+	positional := fs.Args()
+	if len(positional) > 0 {
+		if opts.configPath != "" {
+			return usageError("the configuration is given both with -config and as an argument")
+		}
+		opts.configPath = positional[0]
+	}
+	if len(positional) > 1 {
+		if len(opts.eventsPaths) > 0 {
+			return usageError("the events are given both with -events and as arguments")
+		}
+		for _, path := range positional[1:] {
+			if err := opts.eventsPaths.Set(path); err != nil {
+				return usageError("%v", err)
+			}
+		}
+	}
+
+	// Without a configuration file the RACE_* environment variables configure
+	// the race. Streams read stdin and -listen reads the network when no
+	// events file is given
+	if len(opts.eventsPaths) == 0 && !opts.stream && opts.listen == "" {
+		if !stdinPiped {
+			return usageError("missing the events file")
+		}
+		opts.eventsPaths = pathList{"-"}
+	}
+	if len(opts.eventsPaths) > 1 && (slices.Contains(opts.eventsPaths, "-") || opts.stream || opts.listen != "") {
+		return usageError("only events files can be merged, not stdin, -stream or -listen")
+	}
+	// Following a race is watching it with provisional standings
+	if opts.follow {
+		opts.watch = true
+	}
+	if opts.watch && (len(opts.eventsPaths) != 1 || opts.eventsPaths[0] == "-" || opts.listen != "") {
+		return usageError("the -watch and -follow flags require a single events file")
+	}
+	// Every session reads the events again, which stdin can only give once
+	if opts.sessions > 1 && (slices.Contains(opts.eventsPaths, "-") || opts.stream && len(opts.eventsPaths) == 0) {
+		return usageError("the -sessions flag requires an events file, not stdin")
+	}
+	if opts.refresh < 0 {
+		return usageError("invalid -refresh interval %s", opts.refresh)
+	}
+	if opts.validate && (len(opts.eventsPaths) != 1 || opts.stream || opts.listen != "" || opts.watch) {
+		return usageError("the -validate flag checks a single events file or stdin")
+	}
+	if opts.quiet && opts.verbose {
+		return usageError("the -quiet and -verbose flags can't be combined")
+	}
+	if opts.appendOutgoing && (len(opts.eventsPaths) != 1 || opts.eventsPaths[0] == "-" ||
+		strings.HasSuffix(opts.eventsPaths[0], ".gz") || opts.watch || opts.stream || opts.listen != "") {
+		return usageError("the -append-outgoing flag requires a single uncompressed events file that is not being watched")
+	}
+
+	return opts, nil
+}
+
+// narrationLevel returns the lowest level of narration written: the -log-level,
+// lowered to debug by -verbose to add why competitors were disqualified, how
+// penalty laps were matched and how planned start times were computed.
+// -quiet discards the narration whatever its level.
+func (opts options) narrationLevel() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.logLevel)); err != nil {
+		return 0, err
+	}
+	if opts.verbose {
+		level = min(level, slog.LevelDebug)
+	}
+
+	return level, nil
+}
+
+// pathList collects the values of a flag that may be repeated. Glob patterns
+// are expanded and must match at least one file.
+type pathList []string
+
+func (l *pathList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *pathList) Set(value string) error {
+	if !strings.ContainsAny(value, "*?[") {
+		*l = append(*l, value)
+		return nil
+	}
+
+	matches, err := filepath.Glob(value)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no events files match %s", value)
+	}
+	*l = append(*l, matches...)
+
+	return nil
+}
+
+// idList collects comma-separated competitor IDs from a flag that may be
+// repeated.
+type idList []int
+
+func (l *idList) String() string {
+	ids := make([]string, len(*l))
+	for i, id := range *l {
+		ids[i] = strconv.Itoa(id)
+	}
+
+	return strings.Join(ids, ",")
+}
+
+func (l *idList) Set(value string) error {
+	for _, field := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return fmt.Errorf("invalid competitor ID %q", field)
+		}
+		*l = append(*l, id)
+	}
+
+	return nil
+}
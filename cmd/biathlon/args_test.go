@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		piped  bool
+		config string
+		events []string
+		check  func(options) bool
+	}{
+		{"positional", []string{"config.json", "events"}, false, "config.json", []string{"events"}, nil},
+		{"flags", []string{"-config", "config.json", "-events", "events"}, false, "config.json", []string{"events"}, nil},
+		{"stdin", []string{"-config", "config.json", "-events", "-"}, false, "config.json", []string{"-"}, nil},
+		{"mixed", []string{"-format", "json", "-quiet", "-events", "events", "config.json"}, false, "config.json", []string{"events"},
+			func(opts options) bool { return opts.format == "json" && opts.quiet }},
+		{"stream from stdin", []string{"-stream", "config.json"}, false, "config.json", nil, nil},
+		{"piped stdin", []string{"config.json"}, true, "config.json", []string{"-"}, nil},
+		{"several files", []string{"config.json", "start", "range", "finish"}, false, "config.json", []string{"start", "range", "finish"}, nil},
+		{"repeated flag", []string{"-events", "start", "-events", "finish", "config.json"}, false, "config.json", []string{"start", "finish"}, nil},
+		{"listen", []string{"-listen", ":9000", "-config", "config.json"}, false, "config.json", nil,
+			func(opts options) bool { return opts.listen == ":9000" }},
+		{"follow", []string{"-follow", "config.json", "events"}, false, "config.json", []string{"events"},
+			func(opts options) bool { return opts.follow && opts.watch && opts.refresh == 10*time.Second }},
+		{"competitors", []string{"-competitors", "7, 12", "-competitors", "3", "config.json", "events"}, false, "config.json", []string{"events"},
+			func(opts options) bool { return slices.Equal(opts.competitors, idList{7, 12, 3}) }},
+		{"version", []string{"-version"}, false, "", nil, func(opts options) bool { return opts.version }},
+		{"configuration from the environment", []string{"-events", "events"}, false, "", []string{"events"}, nil},
+		{"defaults", []string{"config.json", "events"}, false, "config.json", []string{"events"},
+			func(opts options) bool {
+				return opts.format == "text" && opts.sessions == 1 && opts.logLevel == "info" && opts.speedDecimals == nil
+			}},
+		{"zero speed decimals", []string{"-speed-decimals", "0", "config.json", "events"}, false, "config.json", []string{"events"},
+			func(opts options) bool { return opts.speedDecimals != nil && *opts.speedDecimals == 0 }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			opts, err := parseArgs(test.args, &stderr, test.piped)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v\n%s", err, stderr.String())
+			}
+			if opts.configPath != test.config || !slices.Equal(opts.eventsPaths, test.events) {
+				t.Errorf("Expected config %q and events %q, got %q and %q", test.config, test.events, opts.configPath, opts.eventsPaths)
+			}
+			if test.check != nil && !test.check(opts) {
+				t.Errorf("Unexpected options %+v", opts)
+			}
+		})
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"unknown flag", []string{"-colour", "config.json", "events"}, "flag provided but not defined: -colour"},
+		{"no arguments", nil, "missing the events file"},
+		{"no events", []string{"config.json"}, "missing the events file"},
+		{"stdin merged", []string{"config.json", "events", "-"}, "only events files can be merged"},
+		{"no glob match", []string{"config.json", "no-such-dir/*.log"}, "no events files match no-such-dir/*.log"},
+		{"config twice", []string{"-config", "config.json", "other.json", "events"}, "both with -config and as an argument"},
+		{"events twice", []string{"-events", "events", "config.json", "other"}, "both with -events and as arguments"},
+		{"watch stdin", []string{"-watch", "-events", "-", "config.json"}, "the -watch and -follow flags require a single events file"},
+		{"follow merged files", []string{"-follow", "config.json", "a", "b"}, "the -watch and -follow flags require a single events file"},
+		{"negative refresh", []string{"-follow", "-refresh", "-1s", "config.json", "events"}, "invalid -refresh interval -1s"},
+		{"sessions from stdin", []string{"-sessions", "2", "config.json", "-"}, "the -sessions flag requires an events file, not stdin"},
+		{"sessions from a stream", []string{"-sessions", "2", "-stream", "config.json"}, "the -sessions flag requires an events file, not stdin"},
+		{"invalid competitor", []string{"-competitors", "7,bib12", "config.json", "events"}, `invalid competitor ID "bib12"`},
+		{"validate merged files", []string{"-validate", "config.json", "start", "finish"}, "the -validate flag checks a single events file or stdin"},
+		{"quiet and verbose", []string{"-quiet", "-v", "config.json", "events"}, "the -quiet and -verbose flags can't be combined"},
+		{"append to merged files", []string{"-append-outgoing", "config.json", "start", "finish"}, "the -append-outgoing flag requires"},
+		{"append to gzip", []string{"-append-outgoing", "config.json", "events.gz"}, "the -append-outgoing flag requires"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			if _, err := parseArgs(test.args, &stderr, false); err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if !strings.Contains(stderr.String(), test.expected) || !strings.Contains(stderr.String(), "Usage: biathlon") {
+				t.Errorf("Expected %q and the usage on stderr, got:\n%s", test.expected, stderr.String())
+			}
+		})
+	}
+
+	var stderr bytes.Buffer
+	if _, err := parseArgs([]string{"-h"}, &stderr, false); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Expected flag.ErrHelp for -h, got %v", err)
+	}
+}
+
+func TestParseArgsGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"finish.log", "range.log", "start.log", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stderr bytes.Buffer
+	opts, err := parseArgs([]string{"-events", filepath.Join(dir, "*.log"), "config.json"}, &stderr, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n%s", err, stderr.String())
+	}
+
+	expected := []string{filepath.Join(dir, "finish.log"), filepath.Join(dir, "range.log"), filepath.Join(dir, "start.log")}
+	if !slices.Equal(opts.eventsPaths, expected) {
+		t.Errorf("Expected %v, got %v", expected, opts.eventsPaths)
+	}
+}
+
+func TestNarrationLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected slog.Level
+	}{
+		{"default", nil, slog.LevelInfo},
+		{"log level", []string{"-log-level", "warn"}, slog.LevelWarn},
+		{"verbose", []string{"-v"}, slog.LevelDebug},
+		{"verbose long", []string{"-verbose"}, slog.LevelDebug},
+		{"verbose lowers the log level", []string{"-v", "-log-level", "error"}, slog.LevelDebug},
+		{"quiet", []string{"-quiet"}, slog.LevelInfo},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			opts, err := parseArgs(append(test.args, "config.json", "events"), &stderr, false)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v\n%s", err, stderr.String())
+			}
+			level, err := opts.narrationLevel()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if level != test.expected {
+				t.Errorf("Expected level %s, got %s", test.expected, level)
+			}
+		})
+	}
+
+	var stderr bytes.Buffer
+	opts, err := parseArgs([]string{"-log-level", "loud", "config.json", "events"}, &stderr, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n%s", err, stderr.String())
+	}
+	if _, err := opts.narrationLevel(); err == nil {
+		t.Errorf("Expected an error for an invalid log level")
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+// dryRun parses, merges and processes the events from sources without writing
+// any commentary, outgoing events or report, and prints a summary of the
+// problems found to w instead. It reports whether any event failed to parse or
+// process.
+func dryRun(ctx context.Context, w io.Writer, sources []biathlon.EventSource, config biathlon.Configuration) (bool, error) {
+	events, err := biathlon.MergeEvents(ctx, sources)
+	var lineErr *biathlon.LineError
+	if err != nil && !errors.As(err, &lineErr) {
+		return false, err
+	}
+	parseErrs := unjoin(err)
+
+	p := biathlon.NewProcessor(config)
+	var processErrs []error
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
+		if err := p.AddEvent(event); err != nil {
+			processErrs = append(processErrs, err)
+		}
+	}
+	p.Finalize()
+
+	fmt.Fprintln(w, "Dry run:")
+	fmt.Fprintf(w, "Events parsed: %d\n", len(events))
+	fmt.Fprintf(w, "Parse errors: %d\n", len(parseErrs))
+	for _, err := range parseErrs {
+		fmt.Fprintf(w, "  %v\n", err)
+	}
+	fmt.Fprintf(w, "Processing errors: %d\n", len(processErrs))
+	for _, err := range processErrs {
+		fmt.Fprintf(w, "  %v\n", err)
+	}
+	fmt.Fprintf(w, "Warnings: %d\n", len(p.Warnings()))
+	for _, warning := range p.Warnings() {
+		fmt.Fprintf(w, "  %v\n", warning)
+	}
+
+	return len(parseErrs) > 0 || len(processErrs) > 0, nil
+}
+
+// unjoin splits an error created by errors.Join into its parts.
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+
+	return []error{err}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+// standings prints the provisional standings of a race being followed every
+// interval, and whenever a line is entered on the terminal.
+type standings struct {
+	print    func() error
+	stderr   io.Writer
+	ticks    <-chan time.Time // nil without an interval
+	requests chan struct{}
+}
+
+// newStandings returns standings printed with print. A zero interval prints
+// them only on request. stop stops the ticker.
+func newStandings(interval time.Duration, print func() error, stderr io.Writer) (s *standings, stop func()) {
+	s = &standings{
+		print:    print,
+		stderr:   stderr,
+		requests: make(chan struct{}, 1),
+	}
+	stop = func() {}
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		s.ticks = ticker.C
+		stop = ticker.Stop
+	}
+
+	return s, stop
+}
+
+// requestOnEnter requests the standings for every line read from in. The
+// goroutine reading it ends with in.
+func (s *standings) requestOnEnter(in io.Reader) {
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			s.request()
+		}
+	}()
+}
+
+// request asks for the standings to be printed at the next refresh.
+func (s *standings) request() {
+	select {
+	case s.requests <- struct{}{}:
+	default:
+	}
+}
+
+// refresh prints the standings if they were requested or the interval has
+// passed. It must only be called between events, e.g. as the caughtUp of a
+// tailReader, as it reads the processor's state.
+func (s *standings) refresh() {
+	select {
+	case <-s.ticks:
+	case <-s.requests:
+	default:
+		return
+	}
+
+	if err := s.print(); err != nil {
+		fmt.Fprintln(s.stderr, "Error printing provisional standings:", err)
+	}
+}
+
+// writeProvisionalStandings writes the standings of the events p has applied
+// so far. They are not finalized: competitors whose start window has passed
+// are disqualified only once, by the Finalize of the final report.
+func writeProvisionalStandings(w io.Writer, p *biathlon.Processor, config biathlon.Configuration, format biathlon.ReportFormat) error {
+	if _, err := fmt.Fprintln(w, "=== Provisional standings ==="); err != nil {
+		return err
+	}
+
+	return biathlon.WriteRaceReport(w, p.Results(), p.RaceState(), config, format)
+}
+
+// followInput is where Enter requests the standings: the terminal, if stdin
+// is one.
+func followInput() io.Reader {
+	if !isTerminal(os.Stdin) {
+		return nil
+	}
+
+	return os.Stdin
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+func TestWriteProvisionalStandings(t *testing.T) {
+	config := biathlon.Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, Start: "10:00:00.000", StartDelta: "00:01:00"}
+	var events []biathlon.EventLog
+	for _, line := range []string{
+		"[09:30:00.000] 1 1",
+		"[09:30:01.000] 1 2",
+		"[10:00:00.000] 4 1",
+		"[10:03:00.000] 5 1 1",
+		"[10:03:30.000] 7 1",
+		"[10:05:00.000] 4 2",
+		"[10:12:00.000] 10 1",
+	} {
+		event, err := biathlon.ParseEventLog(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+
+	// Competitor 2 starts after their window: the standings printed before
+	// that must not disqualify them a second time
+	process := func(refresh bool) (outgoing, standings string) {
+		var out, report bytes.Buffer
+		p := biathlon.NewProcessor(config, biathlon.WithOutgoing(&out))
+		for _, event := range events {
+			if refresh {
+				if err := writeProvisionalStandings(&report, p, config, biathlon.FormatText); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := p.AddEvent(event); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := biathlon.WriteRaceReport(io.Discard, p.Finalize(), p.RaceState(), config, biathlon.FormatText); err != nil {
+			t.Fatal(err)
+		}
+		return out.String(), report.String()
+	}
+
+	expected, _ := process(false)
+	outgoing, standings := process(true)
+	if outgoing != expected {
+		t.Errorf("Expected the outgoing events unchanged by the standings:\n%s\ngot:\n%s", expected, outgoing)
+	}
+	if strings.Count(standings, "=== Provisional standings ===") != len(events) {
+		t.Errorf("Expected standings before every event, got:\n%s", standings)
+	}
+}
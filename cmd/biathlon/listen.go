@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// listenForEvents waits on addr for a single connection from a timing gate and
+// returns it as the event source. Cancelling ctx stops waiting.
+//
+// If timeout is positive and no data arrives on the connection for that long,
+// the connection is treated as finished, so a stale gate ends the input instead
+// of hanging the race.
+func listenForEvents(ctx context.Context, addr string, timeout time.Duration) (io.ReadCloser, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+
+	return &deadlineConn{Conn: conn, timeout: timeout}, nil
+}
+
+// deadlineConn renews the read deadline before every read and reports an
+// expired deadline as the end of the input.
+type deadlineConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *deadlineConn) Read(b []byte) (int, error) {
+	if c.timeout > 0 {
+		if err := c.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := c.Conn.Read(b)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return n, io.EOF
+	}
+
+	return n, err
+}
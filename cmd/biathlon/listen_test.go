@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a loopback address with a port nobody listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	return addr
+}
+
+func TestListenForEventsTimeout(t *testing.T) {
+	addr := freeAddr(t)
+
+	type result struct {
+		input io.ReadCloser
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		input, err := listenForEvents(context.Background(), addr, 50*time.Millisecond)
+		results <- result{input, err}
+	}()
+
+	// The listener may not be up yet
+	var conn net.Conn
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error connecting: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, "[09:05:59.867] 1 1\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	res := <-results
+	if res.err != nil {
+		t.Fatalf("Unexpected error listening: %v", res.err)
+	}
+	defer res.input.Close()
+
+	// The gate stays connected but silent, which ends the input
+	data, err := io.ReadAll(res.input)
+	if err != nil {
+		t.Fatalf("Expected the silent connection to end the input, got %v", err)
+	}
+	if string(data) != "[09:05:59.867] 1 1\n" {
+		t.Errorf("Expected the events sent, got %q", data)
+	}
+}
+
+func TestListenForEventsCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := listenForEvents(ctx, freeAddr(t), 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+// Exit codes of the program.
+const (
+	exitOK     = 0
+	exitUsage  = 1 // invalid command line
+	exitConfig = 2 // the configuration or another race input can't be loaded
+	exitIO     = 3 // reading the events or writing the results failed
+	exitEvents = 4 // invalid events in strict mode, or errors found by -dry-run or -validate
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the program with the command line args, without the program name,
+// and returns its exit code. The report goes to stdout unless -out is given;
+// errors, warnings and by default the narration go to stderr.
+func run(argv []string, stdout, stderr io.Writer) int {
+	args, err := parseArgs(argv, stderr, !isTerminal(os.Stdin))
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	} else if err != nil {
+		return exitUsage
+	}
+
+	if args.version {
+		fmt.Fprintln(stdout, versionString())
+		return exitOK
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	level, err := args.narrationLevel()
+	if err != nil {
+		fmt.Fprintln(stderr, "Invalid log level:", args.logLevel)
+		return exitUsage
+	}
+
+	// The narration goes to its own sink, so the report on stdout can be
+	// redirected on its own
+	logWriter := stderr
+	if args.quiet {
+		logWriter = io.Discard
+	} else if args.logPath != "" {
+		logFile, err := os.Create(args.logPath)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error creating log file:", err)
+			return exitIO
+		}
+		defer logFile.Close()
+		logWriter = logFile
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch args.logFormat {
+	case "text":
+		handler = biathlon.NewNarrationHandler(logWriter, handlerOptions)
+	case "json":
+		handler = slog.NewJSONHandler(logWriter, handlerOptions)
+	default:
+		fmt.Fprintln(stderr, "Invalid log format:", args.logFormat)
+		return exitUsage
+	}
+	if len(args.competitors) > 0 {
+		handler = biathlon.NewCompetitorFilter(handler, args.competitors)
+	}
+	logger := slog.New(handler)
+
+	config, fields, err := biathlon.LoadConfiguration(args.configPath, biathlon.ConfigFormat(args.configFormat))
+	if err != nil {
+		fmt.Fprintln(stderr, "Error loading configuration:", err)
+		return exitConfig
+	}
+	for _, field := range fields.Unknown {
+		fmt.Fprintf(stderr, "Warning: unknown configuration field %q\n", field)
+	}
+	if args.verbose && len(fields.Defaulted) > 0 {
+		fmt.Fprintln(stderr, "Using default configuration for:", strings.Join(fields.Defaulted, ", "))
+	}
+
+	// Configuration precedence: flags > BIATHLON_* environment > file, or the
+	// RACE_* environment when there is no file
+	if err := config.ApplyEnv(); err != nil {
+		fmt.Fprintln(stderr, "Invalid configuration environment:", err)
+		return exitConfig
+	}
+	applyFlags := func(config *biathlon.Configuration) {
+		if args.speedUnit != "" {
+			config.SpeedUnit = args.speedUnit
+		}
+		if args.speedDecimals != nil {
+			config.SpeedDecimals = args.speedDecimals
+		}
+		if args.nationScoreCount != 0 {
+			config.NationScoreCount = args.nationScoreCount
+		}
+		if len(args.competitors) > 0 {
+			config.Competitors = args.competitors
+		}
+		config.Generator = versionString()
+	}
+	applyFlags(&config)
+
+	if err := config.Validate(); err != nil {
+		fmt.Fprintln(stderr, "Invalid configuration:", err)
+		return exitConfig
+	}
+
+	if args.sessions < 1 {
+		fmt.Fprintln(stderr, "Invalid number of sessions:", args.sessions)
+		return exitUsage
+	}
+
+	if args.validate {
+		events, err := openEvents(args.eventsPaths[0])
+		if err != nil {
+			fmt.Fprintln(stderr, "Error opening events file:", err)
+			return exitIO
+		}
+		defer events.Close()
+
+		failed, err := validate(ctx, stdout, events, config)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error reading events:", err)
+			return exitIO
+		}
+		if failed {
+			return exitEvents
+		}
+		return exitOK
+	}
+
+	if args.dryRun {
+		sources, closeAll, err := openEventSources(args.eventsPaths)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error opening events file:", err)
+			return exitIO
+		}
+		defer closeAll()
+
+		failed, err := dryRun(ctx, stdout, sources, config)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error reading events:", err)
+			return exitIO
+		}
+		if failed {
+			return exitEvents
+		}
+		return exitOK
+	}
+
+	outgoing := logWriter
+	if args.outEventsPath != "" {
+		outEventsFile, err := os.Create(args.outEventsPath)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error creating outgoing events file:", err)
+			return exitIO
+		}
+		defer outEventsFile.Close()
+		outgoing = outEventsFile
+	}
+
+	// The events file is read in full before any outgoing event is appended
+	if args.appendOutgoing {
+		auditFile, err := openAuditLog(args.eventsPaths[0])
+		if err != nil {
+			fmt.Fprintln(stderr, "Error opening events file for outgoing events:", err)
+			return exitIO
+		}
+		defer auditFile.Close()
+		outgoing = io.MultiWriter(outgoing, auditFile)
+	}
+
+	var names map[int]string
+	if args.namesPath != "" {
+		namesFile, err := os.Open(args.namesPath)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error opening names file:", err)
+			return exitConfig
+		}
+		defer namesFile.Close()
+
+		names, err = biathlon.ParseNames(namesFile)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error parsing names:", err)
+			return exitConfig
+		}
+	}
+
+	report := stdout
+	if args.outPath != "" {
+		reportFile, err := os.Create(args.outPath)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error creating report file:", err)
+			return exitIO
+		}
+		defer reportFile.Close()
+		report = reportFile
+	}
+
+	// The text report is colored when it goes to a terminal
+	reportFormat := biathlon.ReportFormat(args.format)
+	if f, ok := report.(*os.File); ok && reportFormat == biathlon.FormatText && isTerminal(f) {
+		reportFormat = biathlon.FormatColor
+	}
+	if args.noColor && reportFormat == biathlon.FormatColor {
+		reportFormat = biathlon.FormatText
+	}
+
+	var htmlTemplate *template.Template
+	if args.templatePath != "" {
+		if reportFormat != biathlon.FormatHTML {
+			fmt.Fprintln(stderr, "The -template flag requires -format html")
+			return exitUsage
+		}
+		htmlTemplate, err = biathlon.ParseHTMLTemplate(args.templatePath)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error parsing report template:", err)
+			return exitConfig
+		}
+	}
+
+	var startList []biathlon.StartListEntry
+	if args.competitorsPath != "" {
+		competitorsFile, err := os.Open(args.competitorsPath)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error opening competitors file:", err)
+			return exitConfig
+		}
+		defer competitorsFile.Close()
+
+		startList, err = biathlon.ParseStartList(competitorsFile)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error parsing competitors file:", err)
+			return exitConfig
+		}
+	}
+
+	var startDeltas map[int]time.Duration
+	if args.startDeltasPath != "" {
+		startDeltasFile, err := os.Open(args.startDeltasPath)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error opening start delta overrides:", err)
+			return exitConfig
+		}
+		defer startDeltasFile.Close()
+
+		startDeltas, err = biathlon.ParseStartDeltaOverrides(startDeltasFile)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error parsing start delta overrides:", err)
+			return exitConfig
+		}
+	}
+
+	mode := biathlon.Lenient
+	if args.strict {
+		mode = biathlon.Strict
+	}
+
+	opts := []biathlon.Option{
+		biathlon.WithNames(names),
+		biathlon.WithStartList(startList),
+		biathlon.WithStartDeltaOverrides(startDeltas),
+		biathlon.WithLogger(logger),
+		biathlon.WithOutgoing(outgoing),
+		biathlon.WithMode(mode),
+	}
+
+	if args.raceDate != "" {
+		date, err := time.Parse(time.DateOnly, args.raceDate)
+		if err != nil {
+			fmt.Fprintln(stderr, "Invalid race date:", args.raceDate)
+			return exitUsage
+		}
+		opts = append(opts, biathlon.WithRaceDate(date))
+	}
+
+	if args.pursuitSource != "" {
+		baseStart, err := time.Parse("15:04:05.000", config.Start)
+		if err != nil {
+			fmt.Fprintln(stderr, "Invalid start time:", err)
+			return exitConfig
+		}
+		starts, err := loadPursuitStartTimes(args.pursuitSource, baseStart)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error loading pursuit start times:", err)
+			return exitConfig
+		}
+		opts = append(opts, biathlon.WithPlannedStartTimes(starts))
+	}
+
+	if args.leaderboardPath != "" {
+		leaderboardFile, err := os.Create(args.leaderboardPath)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error creating leaderboard file:", err)
+			return exitIO
+		}
+		defer leaderboardFile.Close()
+		opts = append(opts, biathlon.WithLeaderboard(leaderboardFile))
+	}
+
+	if args.metricsAddr != "" {
+		metrics := biathlon.NewMetrics()
+		_, stopMetrics, err := serveMetrics(args.metricsAddr, metrics)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error serving metrics:", err)
+			return exitIO
+		}
+		defer stopMetrics()
+		opts = append(opts, biathlon.WithMetrics(metrics))
+	}
+
+	s := session{
+		stream:       args.stream,
+		watch:        args.watch,
+		pollInterval: args.pollInterval,
+		listen:       args.listen,
+		readTimeout:  args.readTimeout,
+		replay:       args.replay,
+		speed:        args.speed,
+		mode:         mode,
+		eventsPaths:  args.eventsPaths,
+		stderr:       stderr,
+	}
+
+	p := biathlon.NewProcessor(config, opts...)
+	if args.follow {
+		standings, stopStandings := newStandings(args.refresh, func() error {
+			return writeProvisionalStandings(report, p, config, reportFormat)
+		}, stderr)
+		defer stopStandings()
+		if in := followInput(); in != nil {
+			standings.requestOnEnter(in)
+		}
+		s.standings = standings
+	}
+
+	for i := 1; i <= args.sessions; i++ {
+		if i > 1 {
+			if err := p.Reset(args.configPath); err != nil {
+				fmt.Fprintf(stderr, "Invalid configuration for session %d: %v\n", i, err)
+				return exitConfig
+			}
+			config = p.Config()
+			applyFlags(&config)
+			fmt.Fprintf(report, "\n=== Session %d ===\n", i)
+		}
+
+		competitors, code := s.run(ctx, p)
+		if code != exitOK {
+			return code
+		}
+		for _, id := range config.Competitors {
+			if _, ok := competitors[id]; !ok {
+				fmt.Fprintf(stderr, "Warning: competitor %d does not appear in the events\n", id)
+			}
+		}
+
+		if args.versionHeader && (reportFormat == biathlon.FormatText || reportFormat == biathlon.FormatColor) {
+			fmt.Fprintln(report, "Generated by", config.Generator)
+		}
+
+		var err error
+		if htmlTemplate != nil {
+			err = biathlon.WriteHTMLReport(report, competitors, config, htmlTemplate)
+		} else {
+			err = biathlon.WriteRaceReport(report, competitors, p.RaceState(), config, reportFormat)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, "Error generating report:", err)
+			return exitIO
+		}
+
+		if args.summary {
+			if err := biathlon.WriteSummary(report, competitors, config); err != nil {
+				fmt.Fprintln(stderr, "Error generating summary:", err)
+				return exitIO
+			}
+		}
+	}
+
+	return exitOK
+}
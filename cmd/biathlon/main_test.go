@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite the golden files in testdata with the current output")
+
+// TestMain runs the program instead of the tests when the test binary is started by
+// runMain, so the tests can capture the output of the whole program.
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv("BIATHLON_TEST_MAIN_ARGS"); ok {
+		os.Exit(run(strings.Fields(args), os.Stdout, os.Stderr))
+	}
+
+	os.Exit(m.Run())
+}
+
+// runMain runs the program with args and returns what it wrote to stdout and
+// stderr.
+func runMain(t *testing.T, args ...string) (stdout, stderr []byte) {
+	t.Helper()
+
+	return runMainWithStdin(t, nil, args...)
+}
+
+// runMainWithStdin is runMain with stdin piped from r.
+func runMainWithStdin(t *testing.T, r io.Reader, args ...string) (stdout, stderr []byte) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Stdin = r
+	cmd.Env = append(os.Environ(), "BIATHLON_TEST_MAIN_ARGS="+strings.Join(args, " "))
+	var errOutput bytes.Buffer
+	cmd.Stderr = &errOutput
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Running %v: %v\n%s", args, err, errOutput.String())
+	}
+
+	return output, errOutput.Bytes()
+}
+
+// checkGolden compares output with the named golden file in testdata.
+func checkGolden(t *testing.T, name string, output []byte) {
+	t.Helper()
+
+	golden := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(golden, output, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Reading golden file (run with -update-golden to create it): %v", err)
+	}
+	if !bytes.Equal(output, expected) {
+		t.Errorf("Output differs from %s (run with -update-golden to accept it):\n%s", golden, output)
+	}
+}
+
+func TestRunStartFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"competitors.json":     `[{"id": 1, "name": "Anna Svensson", "nation": "SWE", "bib": 7}]`,
+		"bad-competitors.json": `[{"id": 1, "name": "Anna Svensson"`,
+		"deltas.json":          `{"1": "00:00:01"}`,
+		"bad-deltas.json":      `{"1": "soon"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := filepath.Join("testdata", "config.json")
+	events := filepath.Join("testdata", "events")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+		stderr   string
+		report   string // contained in the report on stdout
+	}{
+		{"competitors file", []string{"-competitors-file", filepath.Join(dir, "competitors.json")}, exitOK, "",
+			"2. [00:25:26.047] 1 Anna Svensson ["},
+		{"malformed competitors file", []string{"-competitors-file", filepath.Join(dir, "bad-competitors.json")}, exitConfig,
+			"Error parsing competitors file", ""},
+		{"missing competitors file", []string{"-competitors-file", filepath.Join(dir, "missing.json")}, exitConfig,
+			"Error opening competitors file", ""},
+		// Competitor 1 started 1.744s after their planned start
+		{"start delta overrides", []string{"-start-delta-overrides", filepath.Join(dir, "deltas.json")}, exitOK, "",
+			"[Disqualified (started outside allowed window: planned 10:00:00.000, actual 10:00:01.744)] 1 "},
+		{"malformed start delta overrides", []string{"-start-delta-overrides", filepath.Join(dir, "bad-deltas.json")}, exitConfig,
+			"Error parsing start delta overrides", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append(append([]string{"-quiet"}, test.args...), config, events)
+			if code := run(args, &stdout, &stderr); code != test.expected {
+				t.Errorf("Expected exit code %d, got %d\n%s", test.expected, code, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), test.stderr) {
+				t.Errorf("Expected %q on stderr, got:\n%s", test.stderr, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), test.report) {
+				t.Errorf("Expected %q in the report, got:\n%s", test.report, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	malformed := filepath.Join(dir, "malformed")
+	if err := os.WriteFile(malformed, []byte("[09:05:59.867] 1 1\nnot an event\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid")
+	if err := os.WriteFile(invalid, []byte("[09:05:59.867] 1 1\n[09:06:00.000] 5 2 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join("testdata", "config.json")
+	events := filepath.Join("testdata", "events")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+		stderr   string
+		report   bool // something is written to stdout
+	}{
+		{"success", []string{config, events}, exitOK, "", true},
+		{"help", []string{"-h"}, exitOK, "Usage: biathlon", false},
+		{"version", []string{"-version"}, exitOK, "", true},
+		{"no configuration", []string{"-events", events}, exitConfig, "no configuration file given", false},
+		{"invalid log level", []string{"-log-level", "loud", config, events}, exitUsage, "Invalid log level: loud", false},
+		{"missing configuration", []string{filepath.Join(dir, "config.json"), events}, exitConfig, "Error loading configuration", false},
+		{"missing events", []string{config, filepath.Join(dir, "events")}, exitIO, "Error opening events file", false},
+		{"malformed line", []string{config, malformed}, exitOK, "Error parsing events", true},
+		{"malformed line in strict mode", []string{"-strict", config, malformed}, exitEvents, "Error parsing events", false},
+		{"invalid event in strict mode", []string{"-strict", config, invalid}, exitEvents, "Error processing events", false},
+		{"invalid event in a stream in strict mode", []string{"-strict", "-stream", config, invalid}, exitEvents, "Error processing events", false},
+		{"dry run problems", []string{"-dry-run", config, malformed}, exitEvents, "", true},
+		{"validate", []string{"-validate", config, events}, exitOK, "", true},
+		{"unknown competitor", []string{"-competitors", "2,9", config, events}, exitOK, "Warning: competitor 9 does not appear in the events", true},
+		{"validate problems", []string{"-validate", config, malformed}, exitEvents, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(append([]string{"-quiet"}, test.args...), &stdout, &stderr); code != test.expected {
+				t.Errorf("Expected exit code %d, got %d\n%s", test.expected, code, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), test.stderr) {
+				t.Errorf("Expected %q on stderr, got:\n%s", test.stderr, stderr.String())
+			}
+			if test.report != (stdout.Len() > 0) {
+				t.Errorf("Expected a report on stdout %v, got:\n%s", test.report, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunEnvConfiguration(t *testing.T) {
+	t.Setenv("RACE_LAPS", "2")
+	t.Setenv("RACE_LAP_LEN", "3500")
+	t.Setenv("RACE_FIRING_LINES", "2")
+	t.Setenv("RACE_START", "10:00:00.000")
+	t.Setenv("RACE_START_DELTA", "00:01:30")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-quiet", "-events", filepath.Join("testdata", "events")}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d\n%s", exitOK, code, stderr.String())
+	}
+	checkGolden(t, "expected_output.golden", stdout.Bytes())
+}
+
+func TestFullPipeline(t *testing.T) {
+	report, narration := runMain(t, filepath.Join("testdata", "config.json"), filepath.Join("testdata", "events"))
+	checkGolden(t, "expected_output.golden", report)
+	checkGolden(t, "expected_log.golden", narration)
+}
+
+func TestQuietToFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "report")
+	stdout, stderr := runMain(t, "-quiet", "-out", out,
+		filepath.Join("testdata", "config.json"), filepath.Join("testdata", "events"))
+	if len(stdout) != 0 || len(stderr) != 0 {
+		t.Errorf("Expected no output with -quiet -out, got stdout %q and stderr %q", stdout, stderr)
+	}
+
+	report, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "expected_output.golden", report)
+}
+
+func TestCSVReport(t *testing.T) {
+	output, _ := runMain(t, "-format", "csv", filepath.Join("testdata", "config.json"), filepath.Join("testdata", "events"))
+	checkGolden(t, "expected_report.csv", output)
+}
+
+func TestMarkdownReport(t *testing.T) {
+	output, _ := runMain(t, "-format", "markdown", filepath.Join("testdata", "config.json"), filepath.Join("testdata", "events"))
+	checkGolden(t, "expected_report.md", output)
+}
+
+func TestWatch(t *testing.T) {
+	events, err := os.ReadFile(filepath.Join("testdata", "events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "events")
+	if err := os.WriteFile(path, events, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The race concludes only after the program has reached the end of the
+	// file and started polling
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer f.Close()
+		if _, err := f.WriteString("[10:45:00.000] 99 0\n"); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	report, _ := runMain(t, "-watch", "-poll-interval", "10ms", filepath.Join("testdata", "config.json"), path)
+	checkGolden(t, "expected_output.golden", report)
+}
+
+func TestFollow(t *testing.T) {
+	events, err := os.ReadFile(filepath.Join("testdata", "events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A line still being written when the race stops being followed is
+	// not processed
+	path := filepath.Join(t.TempDir(), "events")
+	if err := os.WriteFile(path, append(events, "[10:59:0"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"-follow", "-refresh", "20ms", "-poll-interval", "10ms", filepath.Join("testdata", "config.json"), path}
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "BIATHLON_TEST_MAIN_ARGS="+strings.Join(args, " "))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("Running %v: %v\n%s", args, err, stderr.String())
+	}
+
+	if !bytes.Contains(stdout.Bytes(), []byte("=== Provisional standings ===")) {
+		t.Errorf("Expected provisional standings, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Stopped following the race") || strings.Contains(stderr.String(), "Error") {
+		t.Errorf("Expected the race to stop being followed without errors, got:\n%s", stderr.String())
+	}
+	expected, err := os.ReadFile(filepath.Join("testdata", "expected_output.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(stdout.Bytes(), expected) {
+		t.Errorf("Expected the final report last, got:\n%s", stdout.String())
+	}
+}
+
+func TestVersionHeader(t *testing.T) {
+	report, _ := runMain(t, "-version-header", filepath.Join("testdata", "config.json"), filepath.Join("testdata", "events"))
+
+	expected, err := os.ReadFile(filepath.Join("testdata", "expected_output.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := "Generated by biathlon dev (commit dev, built dev)\n"
+	if string(report) != header+string(expected) {
+		t.Errorf("Expected the report after %q, got:\n%s", header, report)
+	}
+}
+
+func TestXMLReport(t *testing.T) {
+	output, _ := runMain(t, "-format", "xml", filepath.Join("testdata", "config.json"), filepath.Join("testdata", "events"))
+	checkGolden(t, "expected_report.xml", output)
+}
+
+func TestGzipEvents(t *testing.T) {
+	events, err := os.ReadFile(filepath.Join("testdata", "events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(events); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the .gz suffix the file is recognised by its magic bytes
+	for _, name := range []string{"events.gz", "events"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, compressed.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		report, narration := runMain(t, filepath.Join("testdata", "config.json"), path)
+		checkGolden(t, "expected_output.golden", report)
+		checkGolden(t, "expected_log.golden", narration)
+	}
+}
+
+func TestAppendOutgoing(t *testing.T) {
+	events, err := os.ReadFile(filepath.Join("testdata", "events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "events")
+	if err := os.WriteFile(path, events, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now()
+	report, _ := runMain(t, "-append-outgoing", filepath.Join("testdata", "config.json"), path)
+	after := time.Now()
+	checkGolden(t, "expected_output.golden", report)
+
+	audited, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(audited, events) {
+		t.Fatalf("Expected the incoming events to be kept")
+	}
+	appended := strings.Split(strings.TrimSuffix(string(audited[len(events):]), "\n"), "\n")
+	for _, line := range appended {
+		event, err := biathlon.ParseEventLog(line)
+		if err != nil {
+			t.Errorf("Appended line %q: %v", line, err)
+		} else if event.EventID != biathlon.EventDisqualified && event.EventID != biathlon.EventFinished {
+			t.Errorf("Expected only outgoing events to be appended, got %q", line)
+		} else if stamp := event.Time.Format("15:04:05.000"); stamp < before.Format("15:04:05.000") || stamp > after.Format("15:04:05.000") {
+			// The comparison fails when the run spans midnight, which is unlikely
+			t.Errorf("Expected line %q to be stamped with the wall clock between %s and %s", line,
+				before.Format("15:04:05.000"), after.Format("15:04:05.000"))
+		}
+	}
+	if len(appended) != 5 {
+		t.Errorf("Expected an outgoing event for every competitor, got %q", appended)
+	}
+
+	// The outgoing events are not applied when the events are processed again
+	report, _ = runMain(t, filepath.Join("testdata", "config.json"), path)
+	checkGolden(t, "expected_output.golden", report)
+}
+
+func TestEventsFromStdin(t *testing.T) {
+	for _, args := range [][]string{
+		{"-config", filepath.Join("testdata", "config.json"), "-events", "-"},
+		{filepath.Join("testdata", "config.json")},
+	} {
+		events, err := os.Open(filepath.Join("testdata", "events"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer events.Close()
+
+		// exec copies a reader that is not a file through a pipe
+		report, narration := runMainWithStdin(t, io.MultiReader(events), args...)
+		checkGolden(t, "expected_output.golden", report)
+		checkGolden(t, "expected_log.golden", narration)
+	}
+}
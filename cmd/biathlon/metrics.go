@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net"
+	"net/http"
+)
+
+// serveMetrics serves handler at /metrics on addr in the background until
+// stop is called. It returns the address listened on, which tells the port
+// when addr asks for any.
+func serveMetrics(addr string, handler http.Handler) (listenAddr net.Addr, stop func(), err error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+
+	return listener.Addr(), func() { server.Close() }, nil
+}
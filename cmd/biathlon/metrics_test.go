@@ -0,0 +1,35 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+func TestServeMetrics(t *testing.T) {
+	addr, stop, err := serveMetrics("127.0.0.1:0", biathlon.NewMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	resp, err := http.Get("http://" + addr.String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Errorf("Expected a text response, got %s %q", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), "race_active_on_course 0\n") {
+		t.Errorf("Expected the metrics of a race not begun, got:\n%s", body)
+	}
+}
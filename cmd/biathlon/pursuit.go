@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+// loadPursuitStartTimes reads the JSON report of a previous race (-format json
+// or json-race) and returns the pursuit start time of every competitor who
+// finished it: baseStart plus their gap to the winner. Competitors who did not
+// finish are left out.
+func loadPursuitStartTimes(path string, baseStart time.Time) (map[int]time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// -format json writes the entries, -format json-race wraps them in a Report
+	var report biathlon.Report
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(data, &report.Results)
+	} else {
+		err = json.Unmarshal(data, &report)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	starts := make(map[int]time.Time)
+	for _, entry := range report.Results {
+		if entry.Status != "Finished" || entry.Gap == nil {
+			continue
+		}
+
+		gap, err := parseGap(*entry.Gap)
+		if err != nil {
+			return nil, fmt.Errorf("%s: competitor %d: invalid gap %q", path, entry.CompetitorID, *entry.Gap)
+		}
+		starts[entry.CompetitorID] = baseStart.Add(gap)
+	}
+
+	return starts, nil
+}
+
+// parseGap parses a gap to the winner as reports write it, "+MM:SS.sss", or as
+// older reports did, "+HH:MM:SS.sss".
+func parseGap(gap string) (time.Duration, error) {
+	text, ok := strings.CutPrefix(gap, "+")
+	if !ok {
+		return 0, errors.New("missing +")
+	}
+	hours := "0"
+	if strings.Count(text, ":") == 2 {
+		hours, text, _ = strings.Cut(text, ":")
+	}
+	minutes, seconds, ok := strings.Cut(text, ":")
+	if !ok {
+		return 0, errors.New("missing minutes")
+	}
+
+	return time.ParseDuration(hours + "h" + minutes + "m" + seconds + "s")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPursuitStartTimesFormats(t *testing.T) {
+	entries := `[{"competitorID": 1, "status": "Finished", "gap": "+00:00.000"}, {"competitorID": 2, "status": "Finished", "gap": "+01:30.500"}]`
+	baseStart := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+	expected := map[int]time.Time{
+		1: baseStart,
+		2: baseStart.Add(90*time.Second + 500*time.Millisecond),
+	}
+
+	for format, report := range map[string]string{
+		"json":      entries,
+		"json-race": `{"race": {}, "results": ` + entries + `}`,
+	} {
+		path := filepath.Join(t.TempDir(), "report.json")
+		if err := os.WriteFile(path, []byte(report), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		starts, err := loadPursuitStartTimes(path, baseStart)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if !reflect.DeepEqual(starts, expected) {
+			t.Errorf("%s: expected start times %v, got %v", format, expected, starts)
+		}
+	}
+}
+
+func TestLoadPursuitStartTimes(t *testing.T) {
+	baseStart := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		report   string
+		expected map[int]time.Time
+		err      string
+	}{
+		{
+			name: "gaps",
+			report: `[{"competitorID": 3, "status": "Finished", "gap": "+00:00.000"},
+				{"competitorID": 1, "status": "Finished", "gap": "+62:03.450"}]`,
+			expected: map[int]time.Time{
+				3: baseStart,
+				1: baseStart.Add(time.Hour + 2*time.Minute + 3*time.Second + 450*time.Millisecond),
+			},
+		},
+		{
+			name:     "gaps of older reports",
+			report:   `[{"competitorID": 1, "status": "Finished", "gap": "+01:02:03.450"}]`,
+			expected: map[int]time.Time{1: baseStart.Add(time.Hour + 2*time.Minute + 3*time.Second + 450*time.Millisecond)},
+		},
+		{
+			name: "non-finishers",
+			report: `[{"competitorID": 1, "status": "Finished", "gap": "+00:00.000"},
+				{"competitorID": 2, "status": "NotFinished", "gap": null},
+				{"competitorID": 4, "status": "Disqualified", "gap": null},
+				{"competitorID": 5, "status": "NotStarted"}]`,
+			expected: map[int]time.Time{1: baseStart},
+		},
+		{
+			name:   "invalid gap",
+			report: `[{"competitorID": 7, "status": "Finished", "gap": "+1 minute"}]`,
+			err:    `competitor 7: invalid gap "+1 minute"`,
+		},
+		{
+			name:   "not a report",
+			report: `"results"`,
+			err:    "cannot unmarshal",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "report.json")
+			if err := os.WriteFile(path, []byte(test.report), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			starts, err := loadPursuitStartTimes(path, baseStart)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("Expected an error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(starts, test.expected) {
+				t.Errorf("Expected start times %v, got %v", test.expected, starts)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+// session describes where the events of a race come from and how they are fed
+// to the processor.
+type session struct {
+	eventsPaths  []string // ["-"] is stdin, as is none in stream mode
+	stream       bool
+	watch        bool       // keep reading the events file as it grows
+	standings    *standings // printed while watching, if set
+	pollInterval time.Duration
+	listen       string
+	readTimeout  time.Duration
+	replay       bool
+	speed        float64
+	mode         biathlon.ProcessingMode
+	stderr       io.Writer // receives errors reading and processing the events
+}
+
+// run processes one race with p and returns the final competitor state with
+// exitOK, or the exit code of the program if there is nothing to report.
+// Errors are reported as they occur. In strict mode a malformed line or an
+// invalid event ends the program.
+//
+// Events from stdin are streamed, as the input may never end; only a replay
+// needs them all up front.
+func (s session) run(ctx context.Context, p *biathlon.Processor) (competitors map[int]*biathlon.Competitor, code int) {
+	stdin := len(s.eventsPaths) == 1 && s.eventsPaths[0] == "-"
+	if s.stream || s.watch || (stdin && !s.replay) {
+		source := io.Reader(os.Stdin)
+		if s.listen != "" {
+			conn, err := listenForEvents(ctx, s.listen, s.readTimeout)
+			if err != nil {
+				fmt.Fprintln(s.stderr, "Error accepting events connection:", err)
+				return nil, exitIO
+			}
+			defer conn.Close()
+			source = conn
+		} else if s.watch {
+			eventsFile, err := os.Open(s.eventsPaths[0])
+			if err != nil {
+				fmt.Fprintln(s.stderr, "Error opening events file:", err)
+				return nil, exitIO
+			}
+			defer eventsFile.Close()
+			tail := &tailReader{ctx: ctx, file: eventsFile, interval: s.pollInterval}
+			if s.standings != nil {
+				tail.caughtUp = s.standings.refresh
+			}
+			source = tail
+		} else if len(s.eventsPaths) > 0 {
+			eventsFile, err := openEvents(s.eventsPaths[0])
+			if err != nil {
+				fmt.Fprintln(s.stderr, "Error opening events file:", err)
+				return nil, exitIO
+			}
+			defer eventsFile.Close()
+			source = eventsFile
+		}
+
+		err := streamEvents(ctx, source, s.stderr, p, s.mode)
+		var eventErr *biathlon.EventError
+		var lineErr *biathlon.LineError
+		switch {
+		case err == nil:
+			return p.Finalize(), exitOK
+		case errors.Is(err, context.Canceled) && s.standings != nil:
+			// The start windows are judged by the latest event seen, not by
+			// the time of the interruption
+			fmt.Fprintln(s.stderr, "Stopped following the race")
+			return p.Finalize(), exitOK
+		case errors.Is(err, context.Canceled):
+			fmt.Fprintln(s.stderr, "Processing interrupted, results are provisional")
+			return p.Results(), exitOK
+		case errors.As(err, &lineErr):
+			fmt.Fprintln(s.stderr, "Error parsing events:", err)
+			return nil, exitEvents
+		case errors.As(err, &eventErr):
+			fmt.Fprintln(s.stderr, "Error processing events:", err)
+			return nil, exitEvents
+		default:
+			fmt.Fprintln(s.stderr, "Error reading events:", err)
+			return nil, exitIO
+		}
+	}
+
+	var events []biathlon.EventLog
+	var err error
+	if s.listen != "" {
+		conn, listenErr := listenForEvents(ctx, s.listen, s.readTimeout)
+		if listenErr != nil {
+			fmt.Fprintln(s.stderr, "Error accepting events connection:", listenErr)
+			return nil, exitIO
+		}
+		defer conn.Close()
+		events, err = biathlon.ReadEvents(ctx, conn)
+	} else {
+		sources, closeAll, openErr := openEventSources(s.eventsPaths)
+		if openErr != nil {
+			fmt.Fprintln(s.stderr, "Error opening events file:", openErr)
+			return nil, exitIO
+		}
+		defer closeAll()
+		events, err = biathlon.MergeEvents(ctx, sources)
+	}
+	if err != nil {
+		var lineErr *biathlon.LineError
+		if !errors.As(err, &lineErr) {
+			fmt.Fprintln(s.stderr, "Error reading events:", err)
+			return nil, exitIO
+		}
+		fmt.Fprintln(s.stderr, "Error parsing events:", err)
+		if s.mode == biathlon.Strict {
+			return nil, exitEvents
+		}
+	}
+
+	if s.replay {
+		err = biathlon.Replay(ctx, events, p, s.speed)
+	} else {
+		err = p.AddEvents(ctx, events)
+	}
+	competitors = p.Results()
+	if err == nil || (!errors.Is(err, context.Canceled) && s.mode == biathlon.Lenient) {
+		competitors = p.Finalize()
+	}
+
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(s.stderr, "Processing interrupted, results are provisional")
+	} else if err != nil {
+		fmt.Fprintln(s.stderr, "Error processing events:", err)
+		if s.mode == biathlon.Strict {
+			return nil, exitEvents
+		}
+	}
+
+	return competitors, exitOK
+}
+
+// openAuditLog opens the events file at path to append outgoing events to,
+// starting a new line first if the file does not end with one. Every event is
+// a single write, so with O_APPEND it is never interleaved with other writers.
+func openAuditLog(path string) (*auditLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() == 0 {
+		return &auditLog{file: file, now: time.Now}, nil
+	}
+
+	events, err := os.Open(path)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	defer events.Close()
+	last := make([]byte, 1)
+	if _, err := events.ReadAt(last, info.Size()-1); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if last[0] != '\n' {
+		if _, err := file.WriteString("\n"); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return &auditLog{file: file, now: time.Now}, nil
+}
+
+// auditLog appends outgoing events to an events file, stamped with the wall
+// clock time they were written at instead of the race time.
+type auditLog struct {
+	file *os.File
+	now  func() time.Time
+}
+
+// Write writes one outgoing event line, e.g. "[10:12:00.000] 33 1".
+func (l *auditLog) Write(b []byte) (int, error) {
+	line := string(b)
+	if _, rest, ok := strings.Cut(line, "] "); ok && strings.HasPrefix(line, "[") {
+		line = "[" + l.now().Format("15:04:05.000") + "] " + rest
+	}
+	if _, err := io.WriteString(l.file, line); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+func (l *auditLog) Close() error {
+	return l.file.Close()
+}
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// openEvents opens the events file at path, or stdin for "-". A file named
+// *.gz or starting with the gzip magic bytes is decompressed as it is read.
+func openEvents(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	buffered := bufio.NewReader(file)
+	magic, _ := buffered.Peek(len(gzipMagic))
+	if !strings.HasSuffix(path, ".gz") && !bytes.Equal(magic, gzipMagic) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{buffered, file}, nil
+	}
+
+	decompressed, err := gzip.NewReader(buffered)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &gzipFile{Reader: decompressed, file: file, path: path}, nil
+}
+
+// gzipFile is a gzip-compressed events file. Errors reading it, such as a
+// truncated stream, name the file.
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+	path string
+}
+
+func (g *gzipFile) Read(b []byte) (int, error) {
+	n, err := g.Reader.Read(b)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = fmt.Errorf("%s: %w", g.path, err)
+	}
+
+	return n, err
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+
+	return g.file.Close()
+}
+
+// openEventSources opens the events files at paths, or stdin for "-", to be
+// merged. Sources are named after their file only when there are several, so
+// parse errors say which one they are in. closeAll closes the files.
+func openEventSources(paths []string) (sources []biathlon.EventSource, closeAll func(), err error) {
+	var files []io.Closer
+	closeAll = func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+
+	for _, path := range paths {
+		file, err := openEvents(path)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		files = append(files, file)
+
+		source := biathlon.EventSource{Reader: file}
+		if len(paths) > 1 {
+			source.Name = path
+		}
+		sources = append(sources, source)
+	}
+
+	return sources, closeAll, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenEventsTruncatedGzip(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write([]byte(strings.Repeat("[09:05:59.867] 1 1\n", 100))); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "events.gz")
+	if err := os.WriteFile(path, compressed.Bytes()[:compressed.Len()/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := openEvents(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer events.Close()
+
+	_, err = io.ReadAll(events)
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Expected an error naming %s, got %v", path, err)
+	}
+}
+
+func TestOpenEventsNotGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.gz")
+	if err := os.WriteFile(path, []byte("[09:05:59.867] 1 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := openEvents(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Expected an error naming %s, got %v", path, err)
+	}
+}
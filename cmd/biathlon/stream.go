@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+// streamEvents feeds events to p as soon as each line of r arrives, so the
+// commentary is written while the race is still running. Malformed lines and
+// invalid events are reported to stderr and skipped, or stop the stream in
+// strict mode. The race-concluded sentinel (event 99) ends the stream.
+// Cancelling ctx stops the stream once the next line has arrived.
+func streamEvents(ctx context.Context, r io.Reader, stderr io.Writer, p *biathlon.Processor, mode biathlon.ProcessingMode) error {
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		event, err := biathlon.ParseEventLog(line)
+		if err != nil {
+			if mode == biathlon.Strict {
+				return &biathlon.LineError{Line: lineNum, Err: err}
+			}
+			fmt.Fprintln(stderr, "Error parsing event:", err)
+			continue
+		}
+		if event.EventID == raceConcludedEvent {
+			return nil
+		}
+
+		if err := p.AddEvent(event); err != nil {
+			if mode == biathlon.Strict {
+				return err
+			}
+			fmt.Fprintln(stderr, "Error processing event:", err)
+		}
+	}
+
+	return scanner.Err()
+}
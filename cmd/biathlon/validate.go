@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+// validate checks every line of r: that it parses, that the event is a legal
+// transition of its competitor's state machine and that the processor accepts
+// it. It prints each problem with its line number to w, followed by a summary
+// such as "312 events, 8 competitors, 0 errors, 2 warnings", and reports
+// whether any errors were found. Nothing is narrated and no report is made.
+func validate(ctx context.Context, w io.Writer, r io.Reader, config biathlon.Configuration) (bool, error) {
+	p := biathlon.NewProcessor(config, biathlon.WithStateValidation(true))
+
+	scanner := bufio.NewScanner(r)
+	lineNum, events, errorCount := 0, 0, 0
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		event, err := biathlon.ParseEventLog(line)
+		if err != nil {
+			fmt.Fprintf(w, "line %d: error: %v\n", lineNum, err)
+			errorCount++
+			continue
+		}
+		events++
+
+		warnings := len(p.Warnings())
+		if err := p.AddEvent(event); err != nil {
+			fmt.Fprintf(w, "line %d: error: %v\n", lineNum, err)
+			errorCount++
+		}
+		for _, warning := range p.Warnings()[warnings:] {
+			fmt.Fprintf(w, "line %d: warning: %v\n", lineNum, warning)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+
+	// Finalize may warn about the race as a whole rather than a line
+	warnings := len(p.Warnings())
+	competitors := len(p.Finalize())
+	for _, warning := range p.Warnings()[warnings:] {
+		fmt.Fprintf(w, "warning: %v\n", warning)
+	}
+	fmt.Fprintf(w, "%d events, %d competitors, %d errors, %d warnings\n",
+		events, competitors, errorCount, len(p.Warnings()))
+
+	return errorCount > 0, nil
+}
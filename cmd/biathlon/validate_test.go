@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"Impulse-GO-Telecom-2025/biathlon"
+)
+
+func TestValidate(t *testing.T) {
+	config := biathlon.Configuration{Laps: 1, LapLen: 3500, PenaltyLen: 150, FiringLines: 1, Start: "10:00:00.000", StartDelta: "00:01:30"}
+
+	tests := []struct {
+		name     string
+		events   string
+		failed   bool
+		expected string
+	}{
+		{"valid", "[09:30:00.000] 1 1\n[10:00:10.000] 4 1\n[10:05:00.000] 5 1 1\n[10:05:30.000] 7 1\n[10:12:00.000] 10 1\n", false,
+			"5 events, 1 competitors, 0 errors, 0 warnings\n"},
+		{"problems", "[09:30:00.000] 1 1\n\nnot an event\n[09:58:00.000] 4 1\n[10:00:10.000] 7 1\n[10:00:20.000] 4 2\n", true,
+			"line 3: error: invalid event log format: not an event\n" +
+				"line 4: warning: [09:58:00.000] event 4 for competitor(1): competitor(1) started before the planned start time 10:00:00.000\n" +
+				"line 5: error: [10:00:10.000] event 7 for competitor(1): event 7 is not allowed in state OnCourse\n" +
+				"line 6: error: [10:00:20.000] event 4 for competitor(2): event 4 is not allowed in state Unregistered\n" +
+				"4 events, 1 competitors, 3 errors, 1 warnings\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			failed, err := validate(context.Background(), &out, strings.NewReader(test.events), config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if failed != test.failed {
+				t.Errorf("Expected failed %v, got %v", test.failed, failed)
+			}
+			if out.String() != test.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", test.expected, out.String())
+			}
+		})
+	}
+}
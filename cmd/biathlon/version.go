@@ -0,0 +1,18 @@
+package main
+
+import "fmt"
+
+// Build information, set when building a release, e.g.
+//
+//	go build -ldflags "-X main.version=1.4.0 -X main.commit=$(git rev-parse --short HEAD) -X main.date=$(date -u +%Y-%m-%d)" ./cmd/biathlon
+var (
+	version = "dev"
+	commit  = "dev"
+	date    = "dev"
+)
+
+// versionString describes the build, e.g.
+// "biathlon 1.4.0 (commit 3f2a9c1, built 2025-06-01)".
+func versionString() string {
+	return fmt.Sprintf("biathlon %s (commit %s, built %s)", version, commit, date)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// raceConcludedEvent is the sentinel event that ends a stream of events, e.g.
+// "[10:45:00.000] 99 0". It is not passed to the processor.
+const raceConcludedEvent = 99
+
+// tailReader reads a file that is still being written to. At the end of the
+// file it waits for new content, checking every interval, until ctx is
+// cancelled. A file truncated while being watched is read again from the start.
+type tailReader struct {
+	ctx      context.Context
+	file     *os.File
+	interval time.Duration
+
+	// caughtUp, if set, is called before every read of the file. A line
+	// scanner only reads once it has returned all the whole lines read so
+	// far, so they have all been processed by then, and a partial last
+	// line waits in the scanner until the rest of it is written.
+	caughtUp func()
+}
+
+func (t *tailReader) Read(b []byte) (int, error) {
+	for {
+		if t.caughtUp != nil {
+			t.caughtUp()
+		}
+		n, err := t.file.Read(b)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+
+		if err := t.rewindIfTruncated(); err != nil {
+			return 0, err
+		}
+
+		timer := time.NewTimer(t.interval)
+		select {
+		case <-t.ctx.Done():
+			timer.Stop()
+			return 0, t.ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// rewindIfTruncated moves back to the start of the file if it is now shorter
+// than the position read up to.
+func (t *tailReader) rewindIfTruncated() error {
+	offset, err := t.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	info, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < offset {
+		_, err = t.file.Seek(0, io.SeekStart)
+	}
+
+	return err
+}
@@ -1,3 +1,8 @@
 module Impulse-GO-Telecom-2025
 
 go 1.23
+
+require (
+	github.com/BurntSushi/toml v1.4.0
+	gopkg.in/yaml.v3 v3.0.1
+)